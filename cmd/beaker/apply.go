@@ -0,0 +1,639 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/allenai/bytefmt"
+	"github.com/beaker/client/api"
+	"github.com/fatih/color"
+	"github.com/spf13/cobra"
+	"gopkg.in/yaml.v3"
+)
+
+const infraLong = `Files declare clusters, workspaces and organizations:
+
+    clusters:
+      - name: my-org/gpu-cloud
+        capacity: 4
+        preemptible: true
+        spec: {cpuCount: 8, gpuCount: 1, gpuType: t4, memory: 60GiB}
+    workspaces:
+      - name: my-org/my-workspace
+        description: Shared experiments
+        public: false
+        permissions: {alice: write, bob: read}
+        secrets: {HF_TOKEN: HF_TOKEN}
+    organizations:
+      - name: my-org
+        members: {alice: admin, bob: member}
+
+Fields which are omitted are left as they are. New clusters must declare a
+spec and have a capacity of 1 unless one is declared. Declared permissions and
+members are exact: accounts which aren't listed are revoked or removed. The
+roles of existing members can't be changed, new members must be declared as
+"member" and you can't remove yourself. Secrets map secret names to
+environment variables holding their values and are written when missing or
+changed; other secrets are left alone.`
+
+func newApplyCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "apply",
+		Short: "Apply declared infrastructure",
+		Long: `Apply declared infrastructure.
+
+Changes are planned as in "beaker plan" and applied after confirmation.
+
+` + infraLong,
+		Args: cobra.NoArgs,
+	}
+
+	var file string
+	var yes bool
+	cmd.Flags().StringVarP(&file, "file", "f", "", "Infrastructure file")
+	cmd.Flags().BoolVarP(&yes, "yes", "y", false, "Apply changes without confirmation")
+	cmd.MarkFlagRequired("file")
+
+	cmd.RunE = func(cmd *cobra.Command, args []string) error {
+		infra, err := readInfraFile(file)
+		if err != nil {
+			return err
+		}
+		changes, err := planInfra(infra)
+		if err != nil {
+			return err
+		}
+		if len(changes) == 0 {
+			fmt.Println("No changes.")
+			return nil
+		}
+
+		printInfraChanges(changes)
+		if !yes {
+			ok, err := confirm(fmt.Sprintf("Apply %d changes?", len(changes)))
+			if err != nil {
+				return err
+			}
+			if !ok {
+				return errors.New("changes not applied")
+			}
+		}
+
+		for i, change := range changes {
+			if err := change.apply(); err != nil {
+				return fmt.Errorf("%s: %w (%d of %d changes applied)", change.String(), err, i, len(changes))
+			}
+		}
+		if !quiet {
+			fmt.Printf("Applied %d changes\n", len(changes))
+		}
+		return nil
+	}
+	return cmd
+}
+
+func newPlanCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "plan",
+		Short: "Show changes needed to apply declared infrastructure",
+		Long: `Show changes needed to apply declared infrastructure.
+
+Nothing is changed. Run "beaker apply" to make the changes.
+
+` + infraLong,
+		Args: cobra.NoArgs,
+	}
+
+	var file string
+	cmd.Flags().StringVarP(&file, "file", "f", "", "Infrastructure file")
+	cmd.MarkFlagRequired("file")
+
+	cmd.RunE = func(cmd *cobra.Command, args []string) error {
+		infra, err := readInfraFile(file)
+		if err != nil {
+			return err
+		}
+		changes, err := planInfra(infra)
+		if err != nil {
+			return err
+		}
+		if len(changes) == 0 {
+			fmt.Println("No changes.")
+			return nil
+		}
+		printInfraChanges(changes)
+		return nil
+	}
+	return cmd
+}
+
+// infraFile declares the desired state of Beaker resources.
+type infraFile struct {
+	Clusters      []infraCluster      `yaml:"clusters"`
+	Workspaces    []infraWorkspace    `yaml:"workspaces"`
+	Organizations []infraOrganization `yaml:"organizations"`
+}
+
+// infraCluster mirrors api.ClusterSpec with a fully scoped name. Fields are
+// pointers so that omitted fields can be told apart from zero values.
+type infraCluster struct {
+	Name        string        `yaml:"name"`
+	Capacity    *int          `yaml:"capacity"`
+	Preemptible *bool         `yaml:"preemptible"`
+	Spec        *clusterShape `yaml:"spec"`
+}
+
+type clusterShape struct {
+	CPUCount float64 `yaml:"cpuCount"`
+	GPUCount int     `yaml:"gpuCount"`
+	GPUType  string  `yaml:"gpuType"`
+	Memory   string  `yaml:"memory"`
+}
+
+func (s clusterShape) nodeResources() (*api.NodeResources, error) {
+	resources := &api.NodeResources{
+		CPUCount: s.CPUCount,
+		GPUCount: s.GPUCount,
+		GPUType:  s.GPUType,
+	}
+	if s.Memory != "" {
+		memory, err := bytefmt.Parse(s.Memory)
+		if err != nil {
+			return nil, err
+		}
+		resources.Memory = memory
+	}
+	return resources, nil
+}
+
+type infraWorkspace struct {
+	Name        string            `yaml:"name"`
+	Description *string           `yaml:"description"`
+	Public      *bool             `yaml:"public"`
+	Archived    *bool             `yaml:"archived"`
+	Permissions map[string]string `yaml:"permissions"`
+	Secrets     map[string]string `yaml:"secrets"`
+}
+
+type infraOrganization struct {
+	Name    string            `yaml:"name"`
+	Members map[string]string `yaml:"members"`
+}
+
+func readInfraFile(path string) (*infraFile, error) {
+	var b []byte
+	var err error
+	if path == "-" {
+		b, err = ioutil.ReadAll(os.Stdin)
+	} else {
+		b, err = ioutil.ReadFile(path)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var infra infraFile
+	dec := yaml.NewDecoder(bytes.NewReader(b))
+	dec.KnownFields(true)
+	if err := dec.Decode(&infra); err != nil {
+		return nil, fmt.Errorf("parsing %s: %w", path, err)
+	}
+	return &infra, nil
+}
+
+// infraChange is a single planned change to a resource.
+type infraChange struct {
+	action   string // One of "create", "update" or "delete".
+	resource string
+	detail   string
+	apply    func() error
+}
+
+func (c infraChange) String() string {
+	if c.detail != "" {
+		return c.resource + ": " + c.detail
+	}
+	return c.action + " " + c.resource
+}
+
+func printInfraChanges(changes []infraChange) {
+	for _, change := range changes {
+		switch change.action {
+		case "create":
+			fmt.Println(color.GreenString("+ " + change.String()))
+		case "delete":
+			fmt.Println(color.RedString("- " + change.String()))
+		default:
+			fmt.Println(color.YellowString("~ " + change.String()))
+		}
+	}
+}
+
+// planInfra compares declared resources with their live state and returns
+// the changes needed to reconcile them, in the order they must be applied.
+func planInfra(infra *infraFile) ([]infraChange, error) {
+	var changes []infraChange
+	for _, cluster := range infra.Clusters {
+		c, err := planCluster(cluster)
+		if err != nil {
+			return nil, fmt.Errorf("cluster %s: %w", cluster.Name, err)
+		}
+		changes = append(changes, c...)
+	}
+	for _, workspace := range infra.Workspaces {
+		c, err := planWorkspace(workspace)
+		if err != nil {
+			return nil, fmt.Errorf("workspace %s: %w", workspace.Name, err)
+		}
+		changes = append(changes, c...)
+	}
+	for _, org := range infra.Organizations {
+		c, err := planOrganization(org)
+		if err != nil {
+			return nil, fmt.Errorf("organization %s: %w", org.Name, err)
+		}
+		changes = append(changes, c...)
+	}
+	return changes, nil
+}
+
+func isNotFound(err error) bool {
+	var apiErr api.Error
+	return errors.As(err, &apiErr) && apiErr.Code == http.StatusNotFound
+}
+
+// splitFullName splits a name in the form account/name.
+func splitFullName(name string) (account, short string, err error) {
+	parts := strings.Split(name, "/")
+	if len(parts) != 2 || parts[0] == "" || parts[1] == "" {
+		return "", "", fmt.Errorf("names must be fully scoped in the form %s", color.GreenString("account/name"))
+	}
+	return parts[0], parts[1], nil
+}
+
+func planCluster(declared infraCluster) ([]infraChange, error) {
+	account, name, err := splitFullName(declared.Name)
+	if err != nil {
+		return nil, err
+	}
+
+	var shape *api.NodeResources
+	if declared.Spec != nil {
+		if shape, err = declared.Spec.nodeResources(); err != nil {
+			return nil, err
+		}
+	}
+	if declared.Capacity != nil && *declared.Capacity < 0 {
+		return nil, fmt.Errorf("capacity must not be negative: %d", *declared.Capacity)
+	}
+
+	cluster, err := beaker.Cluster(declared.Name).Get(ctx)
+	if isNotFound(err) {
+		// New clusters default to a single node, as in "beaker cluster create".
+		spec := api.ClusterSpec{Name: name, Capacity: 1, Spec: shape}
+		if declared.Capacity != nil {
+			spec.Capacity = *declared.Capacity
+		}
+		if declared.Preemptible != nil {
+			spec.Preemptible = *declared.Preemptible
+		}
+		if err := validateClusterSpec(spec); err != nil {
+			return nil, err
+		}
+		return []infraChange{{
+			action:   "create",
+			resource: "cluster " + declared.Name,
+			apply: func() error {
+				_, err := beaker.CreateCluster(ctx, account, spec)
+				return err
+			},
+		}}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if !cluster.Autoscale {
+		return nil, errors.New("only cloud clusters can be declared")
+	}
+	if (declared.Preemptible != nil && cluster.Preemptible != *declared.Preemptible) ||
+		(shape != nil && !equalNodeResources(&cluster.NodeSpec, shape)) {
+		return nil, errors.New("the node shape and preemptibility of a cluster can't be changed in place; " +
+			"delete it or declare a new cluster")
+	}
+	if declared.Capacity == nil || cluster.Capacity == *declared.Capacity {
+		return nil, nil
+	}
+	capacity := *declared.Capacity
+	return []infraChange{{
+		action:   "update",
+		resource: "cluster " + declared.Name,
+		detail:   fmt.Sprintf("capacity %d -> %d", cluster.Capacity, capacity),
+		apply: func() error {
+			_, err := beaker.Cluster(declared.Name).Patch(ctx, &api.ClusterPatch{Capacity: &capacity})
+			return err
+		},
+	}}, nil
+}
+
+func planWorkspace(declared infraWorkspace) ([]infraChange, error) {
+	account, name, err := splitFullName(declared.Name)
+	if err != nil {
+		return nil, err
+	}
+
+	// Resolve declared permissions and secrets first to catch mistakes before
+	// anything is changed.
+	authorizations := make(map[string]api.Permission) // By user ID.
+	accountNames := make(map[string]string)           // By user ID.
+	for accountName, p := range declared.Permissions {
+		permission, err := parsePermission(p)
+		if err != nil {
+			return nil, err
+		}
+		user, err := beaker.User(accountName).Get(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("account %s: %w", accountName, err)
+		}
+		authorizations[user.ID] = permission
+		accountNames[user.ID] = accountName
+	}
+	secrets := make(map[string][]byte)
+	for secret, env := range declared.Secrets {
+		value, ok := os.LookupEnv(env)
+		if !ok {
+			return nil, fmt.Errorf("secret %s: environment variable %s is not set", secret, env)
+		}
+		secrets[secret] = []byte(value)
+	}
+
+	var changes []infraChange
+	handle := beaker.Workspace(declared.Name)
+	resource := "workspace " + declared.Name
+
+	var public bool
+	var currentAuth map[string]api.Permission
+	workspace, err := handle.Get(ctx)
+	switch {
+	case isNotFound(err):
+		spec := api.WorkspaceSpec{Name: name, Organization: account}
+		if declared.Description != nil {
+			spec.Description = *declared.Description
+		}
+		if declared.Public != nil {
+			spec.Public = *declared.Public
+			public = spec.Public
+		}
+		changes = append(changes, infraChange{
+			action:   "create",
+			resource: resource,
+			apply: func() error {
+				_, err := beaker.CreateWorkspace(ctx, spec)
+				return err
+			},
+		})
+		if declared.Archived != nil && *declared.Archived {
+			changes = append(changes, infraChange{
+				action:   "update",
+				resource: resource,
+				detail:   "archive",
+				apply: func() error {
+					_, err := handle.Patch(ctx, api.WorkspacePatch{Archive: api.BoolPtr(true)})
+					return err
+				},
+			})
+		}
+
+	case err != nil:
+		return nil, err
+
+	default:
+		if d := declared.Description; d != nil && *d != workspace.Description {
+			changes = append(changes, infraChange{
+				action:   "update",
+				resource: resource,
+				detail:   fmt.Sprintf("description %q -> %q", workspace.Description, *d),
+				apply: func() error {
+					_, err := handle.Patch(ctx, api.WorkspacePatch{Description: d})
+					return err
+				},
+			})
+		}
+		if a := declared.Archived; a != nil && *a != workspace.Archived {
+			detail := "unarchive"
+			if *a {
+				detail = "archive"
+			}
+			changes = append(changes, infraChange{
+				action:   "update",
+				resource: resource,
+				detail:   detail,
+				apply: func() error {
+					_, err := handle.Patch(ctx, api.WorkspacePatch{Archive: a})
+					return err
+				},
+			})
+		}
+
+		permissions, err := handle.Permissions(ctx)
+		if err != nil {
+			return nil, err
+		}
+		public = permissions.Public
+		currentAuth = permissions.Authorizations
+	}
+
+	if p := declared.Public; p != nil && *p != public {
+		visibility := "private"
+		if *p {
+			visibility = "public"
+		}
+		changes = append(changes, infraChange{
+			action:   "update",
+			resource: resource,
+			detail:   "make " + visibility,
+			apply: func() error {
+				return handle.SetPermissions(ctx, api.WorkspacePermissionPatch{Public: p})
+			},
+		})
+	}
+
+	if declared.Permissions != nil {
+		var ids []string
+		for id := range authorizations {
+			ids = append(ids, id)
+		}
+		for id := range currentAuth {
+			if _, ok := authorizations[id]; !ok {
+				ids = append(ids, id)
+			}
+		}
+		sort.Strings(ids)
+
+		for _, id := range ids {
+			id := id
+			want, isDeclared := authorizations[id]
+			have, isGranted := currentAuth[id]
+			if isDeclared && isGranted && want == have {
+				continue
+			}
+
+			accountName := accountNames[id]
+			if accountName == "" {
+				user, err := beaker.User(id).Get(ctx)
+				if err != nil {
+					return nil, err
+				}
+				accountName = user.Name
+			}
+			change := infraChange{
+				action:   "update",
+				resource: resource,
+				detail:   fmt.Sprintf("grant %s to %s", want, accountName),
+			}
+			if !isDeclared {
+				want = api.NoPermission
+				change.action = "delete"
+				change.detail = fmt.Sprintf("revoke %s from %s", have, accountName)
+			}
+			change.apply = func() error {
+				return handle.SetPermissions(ctx, api.WorkspacePermissionPatch{
+					Authorizations: map[string]api.Permission{id: want},
+				})
+			}
+			changes = append(changes, change)
+		}
+	}
+
+	var secretNames []string
+	for secret := range secrets {
+		secretNames = append(secretNames, secret)
+	}
+	sort.Strings(secretNames)
+	for _, secret := range secretNames {
+		secret, value := secret, secrets[secret]
+		action := "create"
+		if workspace != nil {
+			current, err := handle.ReadSecret(ctx, secret)
+			switch {
+			case isNotFound(err):
+			case err != nil:
+				return nil, err
+			case string(current) == string(value):
+				continue
+			default:
+				action = "update"
+			}
+		}
+		changes = append(changes, infraChange{
+			action:   action,
+			resource: fmt.Sprintf("secret %s in %s", secret, declared.Name),
+			apply: func() error {
+				_, err := handle.PutSecret(ctx, secret, value)
+				return err
+			},
+		})
+	}
+	return changes, nil
+}
+
+func parsePermission(p string) (api.Permission, error) {
+	switch p {
+	case "read":
+		return api.Read, nil
+	case "write":
+		return api.Write, nil
+	case "all":
+		return api.FullControl, nil
+	default:
+		return "", fmt.Errorf(`invalid permission: %q; must be "read", "write", or "all"`, p)
+	}
+}
+
+func planOrganization(declared infraOrganization) ([]infraChange, error) {
+	if declared.Members == nil {
+		return nil, nil
+	}
+
+	org := beaker.Organization(declared.Name)
+	resource := "organization " + declared.Name
+	for account, role := range declared.Members {
+		if role != "admin" && role != "member" {
+			return nil, fmt.Errorf(`invalid role for %s: %q; must be "admin" or "member"`, account, role)
+		}
+	}
+
+	current := make(map[string]string) // Role by account name.
+	var cursor string
+	for {
+		page, next, err := org.ListMembers(ctx, cursor)
+		if err != nil {
+			return nil, err
+		}
+		for _, user := range page {
+			member, err := org.GetMember(ctx, user.Name)
+			if err != nil {
+				return nil, err
+			}
+			current[user.Name] = member.Role
+		}
+		if cursor = next; cursor == "" {
+			break
+		}
+	}
+
+	var accounts []string
+	for account := range declared.Members {
+		accounts = append(accounts, account)
+	}
+	for account := range current {
+		if _, ok := declared.Members[account]; !ok {
+			accounts = append(accounts, account)
+		}
+	}
+	sort.Strings(accounts)
+
+	self, err := beaker.WhoAmI(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var changes []infraChange
+	for _, account := range accounts {
+		account := account
+		want, isDeclared := declared.Members[account]
+		have, isMember := current[account]
+		switch {
+		case !isDeclared && account == self.Name:
+			return nil, fmt.Errorf("%s isn't declared as a member; you can't remove yourself", account)
+		case !isDeclared:
+			changes = append(changes, infraChange{
+				action:   "delete",
+				resource: resource,
+				detail:   "remove member " + account,
+				apply:    func() error { return org.RemoveMember(ctx, account) },
+			})
+		case !isMember && want != "member":
+			// The client can add members but can't set their role.
+			return nil, fmt.Errorf("can't add %s as %s; "+
+				"add them as a member and change their role in the Beaker UI", account, want)
+		case !isMember:
+			changes = append(changes, infraChange{
+				action:   "create",
+				resource: resource,
+				detail:   "add member " + account,
+				apply:    func() error { return org.SetMember(ctx, account, want) },
+			})
+		case want != have:
+			return nil, fmt.Errorf("can't change the role of %s from %s to %s; "+
+				"roles of existing members must be changed in the Beaker UI", account, have, want)
+		}
+	}
+	return changes, nil
+}
@@ -0,0 +1,212 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+
+	"github.com/beaker/client/api"
+	"github.com/beaker/client/client"
+	"github.com/fatih/color"
+	"github.com/spf13/cobra"
+)
+
+// bulkConcurrency limits how many experiments are changed at once.
+const bulkConcurrency = 8
+
+// experimentFilterFlags select experiments for bulk operations.
+type experimentFilterFlags struct {
+	workspace string
+	author    string
+	status    string
+	text      string
+	yes       bool
+}
+
+func addExperimentFilterFlags(cmd *cobra.Command) *experimentFilterFlags {
+	flags := &experimentFilterFlags{}
+	cmd.Flags().StringVarP(&flags.workspace, "workspace", "w", "", "Workspace to search, defaults to the default workspace")
+	cmd.Flags().StringVar(&flags.author, "author", "", `Only match experiments by an author, or "me"`)
+	cmd.Flags().StringVar(&flags.status, "status", "", "Only match experiments with a job in a status: "+
+		strings.Join(experimentStatuses, ", "))
+	cmd.Flags().StringVar(&flags.text, "text", "", "Only match experiments matching the text")
+	cmd.Flags().BoolVarP(&flags.yes, "yes", "y", false, "Don't ask for confirmation")
+	return flags
+}
+
+// experimentStatuses are the statuses reported by jobStatus.
+var experimentStatuses = []string{"pending", "starting", "running", "uploading", "succeeded", "failed"}
+
+// isSet returns whether any filter flag was given, including the workspace.
+func (f *experimentFilterFlags) isSet(cmd *cobra.Command) bool {
+	return cmd.Flags().Changed("workspace") || f.narrows(cmd)
+}
+
+// narrows returns whether a filter narrows the experiments within the
+// workspace. The workspace alone only sets the scope, so bulk changes require
+// at least one of these to avoid acting on every experiment in a workspace.
+func (f *experimentFilterFlags) narrows(cmd *cobra.Command) bool {
+	for _, name := range []string{"author", "status", "text"} {
+		if cmd.Flags().Changed(name) {
+			return true
+		}
+	}
+	return false
+}
+
+// list finds experiments in the workspace matching the filters.
+func (f *experimentFilterFlags) list() ([]api.Experiment, error) {
+	workspace := f.workspace
+	if workspace == "" {
+		workspace = beakerConfig.DefaultWorkspace
+	}
+	if workspace == "" {
+		return nil, errors.New("no workspace given; use --workspace or set a default workspace")
+	}
+
+	if f.status != "" {
+		valid := false
+		for _, s := range experimentStatuses {
+			valid = valid || s == f.status
+		}
+		if !valid {
+			return nil, fmt.Errorf("invalid status: %q; must be one of %s",
+				f.status, strings.Join(experimentStatuses, ", "))
+		}
+	}
+
+	author := f.author
+	if author == "me" {
+		user, err := beaker.WhoAmI(ctx)
+		if err != nil {
+			return nil, err
+		}
+		author = user.Name
+	}
+
+	var experiments []api.Experiment
+	var cursor string
+	for {
+		page, next, err := beaker.Workspace(workspace).Experiments(ctx, &client.ListExperimentOptions{
+			Cursor: cursor,
+			Text:   f.text,
+		})
+		if err != nil {
+			return nil, err
+		}
+		for _, experiment := range page {
+			if author != "" && experiment.Author.Name != author {
+				continue
+			}
+			if f.status != "" && !hasJobStatus(experiment.Jobs, f.status) {
+				continue
+			}
+			experiments = append(experiments, experiment)
+		}
+		if cursor = next; cursor == "" {
+			break
+		}
+	}
+	return experiments, nil
+}
+
+// hasJobStatus returns whether the latest job of any task has a status.
+// Earlier jobs are ignored so that e.g. a task which failed and then succeeded
+// on a retry doesn't count as failed.
+func hasJobStatus(jobs []api.Job, status string) bool {
+	latest := make(map[string]api.Job) // By task ID.
+	for _, job := range jobs {
+		var task string
+		if job.Execution != nil {
+			task = job.Execution.Task
+		}
+		if last, ok := latest[task]; !ok || job.Status.Created.After(last.Status.Created) {
+			latest[task] = job
+		}
+	}
+	for _, job := range latest {
+		if jobStatus(job.Status) == status {
+			return true
+		}
+	}
+	return false
+}
+
+// confirmExperiments shows matched experiments and asks whether to proceed.
+// Returns false if there's nothing to do or the user declines.
+func confirmExperiments(experiments []api.Experiment, verb string, yes bool) (bool, error) {
+	if len(experiments) == 0 {
+		fmt.Println("No matching experiments.")
+		return false, nil
+	}
+	if yes {
+		return true, nil
+	}
+
+	if err := printExperiments(experiments); err != nil {
+		return false, err
+	}
+	if err := tableOut.Flush(); err != nil {
+		return false, err
+	}
+	fmt.Println()
+	return confirm(fmt.Sprintf("%s %d experiments?", capitalize(verb), len(experiments)))
+}
+
+// bulkExperiments runs an action on each experiment with bounded concurrency
+// and prints the outcome for each one. The action is described by a verb and
+// its past tense, e.g. "stop" and "stopped".
+func bulkExperiments(
+	experiments []api.Experiment,
+	verb, pastVerb string,
+	f func(api.Experiment) error,
+) error {
+	errs := make([]error, len(experiments))
+	sem := make(chan struct{}, bulkConcurrency)
+	var wg sync.WaitGroup
+	for i, experiment := range experiments {
+		wg.Add(1)
+		sem <- struct{}{}
+		go func(i int, experiment api.Experiment) {
+			defer wg.Done()
+			defer func() { <-sem }()
+			errs[i] = f(experiment)
+		}(i, experiment)
+	}
+	wg.Wait()
+
+	var failed int
+	for i, experiment := range experiments {
+		name := experiment.ID
+		if experiment.FullName != "" {
+			name = experiment.FullName
+		}
+		if errs[i] != nil {
+			failed++
+			fmt.Fprintln(os.Stderr, color.RedString("Error:"), name, errs[i])
+			continue
+		}
+		if quiet {
+			fmt.Println(experiment.ID)
+		} else {
+			fmt.Printf("%s %s\n", color.GreenString("OK:"), name)
+		}
+	}
+
+	if failed > 0 {
+		return fmt.Errorf("failed to %s %d of %d experiments", verb, failed, len(experiments))
+	}
+	if !quiet {
+		fmt.Printf("%s %d experiments\n", capitalize(pastVerb), len(experiments))
+	}
+	return nil
+}
+
+func capitalize(s string) string {
+	if s == "" {
+		return s
+	}
+	return strings.ToUpper(s[:1]) + s[1:]
+}
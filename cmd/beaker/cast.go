@@ -0,0 +1,230 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"sync"
+	"time"
+	"unicode/utf8"
+
+	"github.com/fatih/color"
+	"github.com/moby/term"
+)
+
+// castHeader is the first line of an asciinema cast file.
+// See https://github.com/asciinema/asciinema/blob/develop/doc/asciicast-v2.md
+type castHeader struct {
+	Version   int               `json:"version"`
+	Width     int               `json:"width"`
+	Height    int               `json:"height"`
+	Timestamp int64             `json:"timestamp,omitempty"`
+	Title     string            `json:"title,omitempty"`
+	Env       map[string]string `json:"env,omitempty"`
+}
+
+// castEvent is a single line of terminal output in a cast file.
+type castEvent struct {
+	Time float64
+	Type string
+	Data string
+}
+
+func (e castEvent) MarshalJSON() ([]byte, error) {
+	return json.Marshal([]interface{}{e.Time, e.Type, e.Data})
+}
+
+func (e *castEvent) UnmarshalJSON(b []byte) error {
+	var fields []json.RawMessage
+	if err := json.Unmarshal(b, &fields); err != nil {
+		return err
+	}
+	if len(fields) != 3 {
+		return fmt.Errorf("expected 3 fields, got %d", len(fields))
+	}
+	if err := json.Unmarshal(fields[0], &e.Time); err != nil {
+		return err
+	}
+	if err := json.Unmarshal(fields[1], &e.Type); err != nil {
+		return err
+	}
+	return json.Unmarshal(fields[2], &e.Data)
+}
+
+// castRecorder writes terminal output to a cast file as it is streamed.
+// Writes never fail so that a problem with the recording can't interrupt the
+// stream being recorded. The first error is logged and the rest of the output
+// is dropped.
+type castRecorder struct {
+	mu    sync.Mutex
+	file  *os.File
+	start time.Time
+	err   error
+
+	// Output may split multi-byte characters across writes. Incomplete
+	// characters are held until the rest of their bytes arrive.
+	pending []byte
+}
+
+// checkCanRecord returns an error if output can't be recorded. Streams are
+// only raw terminal output when stdout is a terminal; otherwise Docker
+// multiplexes them and the recording would contain its frame headers.
+func checkCanRecord() error {
+	if !term.IsTerminal(os.Stdout.Fd()) {
+		return errors.New("--record requires stdout to be a terminal")
+	}
+	return nil
+}
+
+// newCastRecorder creates a cast file sized to the current terminal.
+func newCastRecorder(path string, title string) (*castRecorder, error) {
+	header := castHeader{
+		Version: 2,
+		Width:   80,
+		Height:  24,
+		Title:   title,
+		Env: map[string]string{
+			"SHELL": os.Getenv("SHELL"),
+			"TERM":  os.Getenv("TERM"),
+		},
+	}
+	if ws, err := term.GetWinsize(os.Stdout.Fd()); err == nil && ws.Width > 0 {
+		header.Width = int(ws.Width)
+		header.Height = int(ws.Height)
+	}
+
+	file, err := os.Create(path)
+	if err != nil {
+		return nil, fmt.Errorf("creating recording: %w", err)
+	}
+
+	start := time.Now()
+	header.Timestamp = start.Unix()
+	b, err := json.Marshal(header)
+	if err != nil {
+		_ = file.Close()
+		return nil, err
+	}
+	if _, err := file.Write(append(b, '\n')); err != nil {
+		_ = file.Close()
+		return nil, fmt.Errorf("writing recording: %w", err)
+	}
+	return &castRecorder{file: file, start: start}, nil
+}
+
+func (r *castRecorder) Write(p []byte) (int, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.err != nil {
+		return len(p), nil
+	}
+
+	data := append(r.pending, p...)
+	n := len(data)
+	for i := 1; i < utf8.UTFMax && i <= len(data); i++ {
+		// Find the start of the last character and hold it if it's incomplete.
+		if utf8.RuneStart(data[len(data)-i]) {
+			if !utf8.FullRune(data[len(data)-i:]) {
+				n = len(data) - i
+			}
+			break
+		}
+	}
+	r.pending = append([]byte(nil), data[n:]...)
+	if n == 0 {
+		return len(p), nil
+	}
+
+	if err := r.writeEvent(string(data[:n])); err != nil {
+		r.err = err
+		fmt.Fprintln(os.Stderr, color.YellowString("Warning:"), "recording stopped:", err)
+	}
+	return len(p), nil
+}
+
+func (r *castRecorder) writeEvent(data string) error {
+	b, err := json.Marshal(castEvent{
+		Time: time.Since(r.start).Seconds(),
+		Type: "o",
+		Data: data,
+	})
+	if err != nil {
+		return err
+	}
+	_, err = r.file.Write(append(b, '\n'))
+	return err
+}
+
+// Close flushes any incomplete output and closes the cast file.
+func (r *castRecorder) Close() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.err == nil && len(r.pending) > 0 {
+		if err := r.writeEvent(string(r.pending)); err != nil {
+			_ = r.file.Close()
+			return err
+		}
+		r.pending = nil
+	}
+	return r.file.Close()
+}
+
+// replayCast plays back the output events of a cast file to w. Pauses
+// between events are divided by speed and capped at maxWait if it's positive.
+func replayCast(r io.Reader, w io.Writer, speed float64, maxWait time.Duration) error {
+	br := bufio.NewReader(r)
+
+	line, err := br.ReadBytes('\n')
+	if err != nil && err != io.EOF {
+		return err
+	}
+	var header castHeader
+	if err := json.Unmarshal(line, &header); err != nil {
+		return fmt.Errorf("invalid recording header: %w", err)
+	}
+	if header.Version != 2 {
+		return fmt.Errorf("unsupported recording version: %d", header.Version)
+	}
+
+	var last float64
+	for lineNum := 2; ; lineNum++ {
+		line, err := br.ReadBytes('\n')
+		if errors.Is(err, io.EOF) && len(line) == 0 {
+			return nil
+		}
+		if err != nil && !errors.Is(err, io.EOF) {
+			return err
+		}
+		if len(bytes.TrimSpace(line)) == 0 {
+			continue
+		}
+
+		var event castEvent
+		if err := json.Unmarshal(line, &event); err != nil {
+			return fmt.Errorf("invalid recording event on line %d: %w", lineNum, err)
+		}
+		if event.Type != "o" {
+			continue
+		}
+
+		wait := time.Duration((event.Time - last) / speed * float64(time.Second))
+		if maxWait > 0 && wait > maxWait {
+			wait = maxWait
+		}
+		last = event.Time
+
+		select {
+		case <-time.After(wait):
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+		if _, err := io.WriteString(w, event.Data); err != nil {
+			return err
+		}
+	}
+}
@@ -3,7 +3,9 @@ package main
 import (
 	"context"
 	"fmt"
+	"sort"
 	"strings"
+	"time"
 
 	"github.com/allenai/bytefmt"
 	"github.com/beaker/client/api"
@@ -17,15 +19,59 @@ func newClusterCommand() *cobra.Command {
 		Use:   "cluster <command>",
 		Short: "Manage clusters",
 	}
+	cmd.AddCommand(newClusterCostCommand())
 	cmd.AddCommand(newClusterCreateCommand())
 	cmd.AddCommand(newClusterDeleteCommand())
+	cmd.AddCommand(newClusterFitCommand())
 	cmd.AddCommand(newClusterGetCommand())
 	cmd.AddCommand(newClusterListCommand())
 	cmd.AddCommand(newClusterNodesCommand())
+	cmd.AddCommand(newClusterQueueCommand())
 	cmd.AddCommand(newClusterUpdateCommand())
 	return cmd
 }
 
+func newClusterCostCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "cost <cluster>",
+		Short: "Estimate the cost of jobs run on a cluster",
+		Long: `Estimate the cost of jobs run on a cluster.
+
+Each job is charged for the time it ran in the reporting period multiplied by
+the cost of its node and the largest share of the node's CPUs, GPUs or memory
+reserved by the job. Only clusters with a node cost, such as cloud clusters,
+incur costs. Use --format=csv for spreadsheets.`,
+		Args: cobra.ExactArgs(1),
+	}
+	flags := addCostFlags(cmd, "author")
+
+	cmd.RunE = func(cmd *cobra.Command, args []string) error {
+		since, err := parseSince(flags.since)
+		if err != nil {
+			return err
+		}
+
+		cluster, err := beaker.Cluster(args[0]).Get(ctx)
+		if err != nil {
+			return err
+		}
+		if cluster.NodeCost == nil && !quiet && format == "" {
+			fmt.Printf("Cluster %s has no node cost; only GPU hours are reported.\n", cluster.FullName)
+		}
+
+		jobs, err := listClusterJobsSince(cluster.FullName, since)
+		if err != nil {
+			return err
+		}
+		costs, err := groupJobCosts(jobs, since, flags.groupBy)
+		if err != nil {
+			return err
+		}
+		return printJobCosts(costs)
+	}
+	return cmd
+}
+
 func newClusterCreateCommand() *cobra.Command {
 	cmd := &cobra.Command{
 		Use:   "create <type>",
@@ -43,24 +89,12 @@ func newClusterCreateCloudCommand() *cobra.Command {
 		Args:  cobra.ExactArgs(1),
 	}
 
-	var maxSize int
-	var preemptible bool
-	var cpuCount float64
-	var gpuCount int
-	var gpuType string
-	var memory string
-
-	cmd.Flags().IntVar(&maxSize, "max-size", 1, "Maximum number of nodes")
-	cmd.Flags().BoolVar(&preemptible, "preemptible", false, "Enable cheaper but more volatile nodes")
-	cmd.Flags().Float64Var(&cpuCount, "cpus", 0, "Minimum CPU cores per node, e.g. 7.5")
-	cmd.Flags().IntVar(&gpuCount, "gpus", 0, "Number of GPUs per node: 1, 2, 4, or 8")
-	cmd.Flags().StringVar(&gpuType, "gpu-type", "", "Type of GPU: a100, k80, p100, p4, t4, or v100")
-	cmd.Flags().StringVar(&memory, "memory", "", "Minimum memory per node, e.g. 6.5GiB")
+	flags := addClusterSpecFlags(cmd, 1)
 
 	// Deprecated flags are replaced by the above.
-	cmd.Flags().Float64Var(&cpuCount, "cpu-count", 0, "")
+	cmd.Flags().Float64Var(&flags.cpuCount, "cpu-count", 0, "")
 	cmd.Flags().MarkDeprecated("cpu-count", "please use --cpus instead")
-	cmd.Flags().IntVar(&gpuCount, "gpu-count", 0, "")
+	cmd.Flags().IntVar(&flags.gpuCount, "gpu-count", 0, "")
 	cmd.Flags().MarkDeprecated("gpu-count", "please use --gpus instead")
 
 	cmd.RunE = func(cmd *cobra.Command, args []string) error {
@@ -70,28 +104,20 @@ func newClusterCreateCloudCommand() *cobra.Command {
 		}
 		account, clusterName := parts[0], parts[1]
 
-		if cpuCount == 0 && gpuCount == 0 && gpuType == "" && memory == "" {
-			return fmt.Errorf("cloud clusters must specify at least 1 resource")
-		}
-
-		var memorySize *bytefmt.Size
-		if memory != "" {
-			var err error
-			if memorySize, err = bytefmt.Parse(memory); err != nil {
-				return err
-			}
+		nodeSpec, err := flags.nodeSpec(cmd, api.NodeResources{})
+		if err != nil {
+			return err
 		}
 		spec := api.ClusterSpec{
 			Name:        clusterName,
-			Capacity:    maxSize,
-			Preemptible: preemptible,
-			Spec: &api.NodeResources{
-				CPUCount: cpuCount,
-				GPUCount: gpuCount,
-				GPUType:  gpuType,
-				Memory:   memorySize,
-			},
+			Capacity:    flags.maxSize,
+			Preemptible: flags.preemptible,
+			Spec:        nodeSpec,
+		}
+		if err := validateClusterSpec(spec); err != nil {
+			return err
 		}
+
 		cluster, err := beaker.CreateCluster(ctx, account, spec)
 		if err != nil {
 			return err
@@ -126,6 +152,123 @@ func newClusterCreateCloudCommand() *cobra.Command {
 	return cmd
 }
 
+// clusterSpecFlags describe the size and node shape of a cloud cluster.
+type clusterSpecFlags struct {
+	maxSize     int
+	preemptible bool
+	cpuCount    float64
+	gpuCount    int
+	gpuType     string
+	memory      string
+}
+
+func addClusterSpecFlags(cmd *cobra.Command, defaultMaxSize int) *clusterSpecFlags {
+	flags := &clusterSpecFlags{}
+	cmd.Flags().IntVar(&flags.maxSize, "max-size", defaultMaxSize, "Maximum number of nodes")
+	cmd.Flags().BoolVar(&flags.preemptible, "preemptible", false, "Enable cheaper but more volatile nodes")
+	cmd.Flags().Float64Var(&flags.cpuCount, "cpus", 0, "Minimum CPU cores per node, e.g. 7.5")
+	cmd.Flags().IntVar(&flags.gpuCount, "gpus", 0, "Number of GPUs per node: 1, 2, 4, or 8")
+	cmd.Flags().StringVar(&flags.gpuType, "gpu-type", "", "Type of GPU: a100, k80, p100, p4, t4, or v100")
+	cmd.Flags().StringVar(&flags.memory, "memory", "", "Minimum memory per node, e.g. 6.5GiB")
+	return flags
+}
+
+// nodeSpec applies node shape flags set on the command line to a base shape.
+func (f *clusterSpecFlags) nodeSpec(cmd *cobra.Command, base api.NodeResources) (*api.NodeResources, error) {
+	changed := cmd.Flags().Changed
+	spec := base
+	if changed("cpus") || changed("cpu-count") {
+		spec.CPUCount = f.cpuCount
+	}
+	if changed("gpus") || changed("gpu-count") {
+		spec.GPUCount = f.gpuCount
+	}
+	if changed("gpu-type") {
+		spec.GPUType = f.gpuType
+	}
+	if changed("memory") {
+		spec.Memory = nil
+		if f.memory != "" {
+			memory, err := bytefmt.Parse(f.memory)
+			if err != nil {
+				return nil, err
+			}
+			spec.Memory = memory
+		}
+	}
+	return &spec, nil
+}
+
+// validateClusterSpec checks a cloud cluster's spec before it's sent to Beaker.
+func validateClusterSpec(spec api.ClusterSpec) error {
+	if spec.Capacity < 0 {
+		return fmt.Errorf("max size must not be negative: %d", spec.Capacity)
+	}
+
+	shape := spec.Spec
+	if shape == nil || (shape.CPUCount == 0 && shape.GPUCount == 0 && shape.GPUType == "" && shape.Memory == nil) {
+		return fmt.Errorf("cloud clusters must specify at least 1 resource")
+	}
+	if shape.CPUCount < 0 {
+		return fmt.Errorf("CPUs must not be negative: %g", shape.CPUCount)
+	}
+	switch shape.GPUCount {
+	case 0, 1, 2, 4, 8:
+	default:
+		return fmt.Errorf("GPUs per node must be 1, 2, 4, or 8: %d", shape.GPUCount)
+	}
+	if shape.Memory != nil && shape.Memory.Int64() <= 0 {
+		return fmt.Errorf("memory must be positive: %s", shape.Memory)
+	}
+	return nil
+}
+
+func equalNodeResources(a, b *api.NodeResources) bool {
+	if a.CPUCount != b.CPUCount || a.GPUCount != b.GPUCount || a.GPUType != b.GPUType {
+		return false
+	}
+	if a.Memory == nil || b.Memory == nil {
+		return a.Memory == b.Memory
+	}
+	return a.Memory.Cmp(*b.Memory) == 0
+}
+
+// clusterSpecLines renders a cluster spec as YAML-like lines for diffing.
+// Every spec renders the same keys in the same order.
+func clusterSpecLines(spec api.ClusterSpec) []string {
+	shape := spec.Spec
+	if shape == nil {
+		shape = &api.NodeResources{}
+	}
+	memory := ""
+	if shape.Memory != nil {
+		memory = shape.Memory.String()
+	}
+	return []string{
+		fmt.Sprintf("name: %s", spec.Name),
+		fmt.Sprintf("capacity: %d", spec.Capacity),
+		fmt.Sprintf("preemptible: %t", spec.Preemptible),
+		"spec:",
+		fmt.Sprintf("  cpuCount: %g", shape.CPUCount),
+		fmt.Sprintf("  gpuCount: %d", shape.GPUCount),
+		fmt.Sprintf("  gpuType: %s", shape.GPUType),
+		fmt.Sprintf("  memory: %s", memory),
+	}
+}
+
+// printClusterSpecDiff prints a line-by-line diff between two cluster specs.
+func printClusterSpecDiff(before, after api.ClusterSpec) {
+	a, b := clusterSpecLines(before), clusterSpecLines(after)
+	for i := range a {
+		if a[i] == b[i] {
+			fmt.Println("  " + a[i])
+			continue
+		}
+		fmt.Println(color.RedString("- " + a[i]))
+		fmt.Println(color.GreenString("+ " + b[i]))
+	}
+}
+
 func newClusterCreateOnPremCommand() *cobra.Command {
 	return &cobra.Command{
 		Use:   "on-prem <name>",
@@ -169,6 +312,90 @@ func newClusterDeleteCommand() *cobra.Command {
 	}
 }
 
+func newClusterFitCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "fit <cluster...>",
+		Short: "Find nodes with enough free resources for a job",
+		Long: `Find nodes with enough free resources for a job.
+
+Nodes which fit are listed first, ordered by the number of jobs already running
+on them and then by how tightly the job fits. Nodes which don't fit are listed
+with the reason why.`,
+		Args: cobra.MinimumNArgs(1),
+	}
+
+	var cpus float64
+	var gpus int
+	var gpuType string
+	var memory string
+	cmd.Flags().Float64Var(&cpus, "cpus", 0, "Number of CPUs required")
+	cmd.Flags().IntVar(&gpus, "gpus", 0, "Number of GPUs required")
+	cmd.Flags().StringVar(&gpuType, "gpu-type", "", "Type of GPU required e.g. A100")
+	cmd.Flags().StringVar(&memory, "memory", "", "Amount of memory required e.g. 200GiB")
+
+	cmd.RunE = func(cmd *cobra.Command, args []string) error {
+		request := &api.ResourceRequest{CPUCount: cpus, GPUCount: gpus}
+		if memory != "" {
+			var err error
+			if request.Memory, err = bytefmt.Parse(memory); err != nil {
+				return fmt.Errorf("invalid value for --memory: %w", err)
+			}
+		}
+		var gpuReq *gpuRequest
+		if gpuType != "" {
+			if gpus == 0 {
+				return fmt.Errorf("--gpu-type requires --gpus")
+			}
+			gpuReq = &gpuRequest{Type: gpuType}
+		}
+
+		var fits []nodeFit
+		for _, cluster := range args {
+			nodes, err := getNodeUtilization(cluster, "")
+			if err != nil {
+				return fmt.Errorf("%s: %w", cluster, err)
+			}
+			for _, node := range nodes {
+				fit := nodeFit{Cluster: cluster, nodeUtilization: node, Fits: true}
+				if err := checkNodeCapacity(node.freeNode(), request, gpuReq); err != nil {
+					fit.Fits = false
+					fit.Reason = err.Error()
+				}
+				fits = append(fits, fit)
+			}
+		}
+
+		sort.SliceStable(fits, func(i, j int) bool {
+			a, b := fits[i], fits[j]
+			if a.Fits != b.Fits {
+				return a.Fits
+			}
+			if a.Jobs != b.Jobs {
+				return a.Jobs < b.Jobs
+			}
+			// Prefer tight fits to leave larger nodes for larger jobs.
+			af, bf := a.Free, b.Free
+			if af == nil || bf == nil {
+				return af != nil
+			}
+			if af.GPUCount != bf.GPUCount {
+				return af.GPUCount < bf.GPUCount
+			}
+			return af.CPUCount < bf.CPUCount
+		})
+		return printNodeFits(fits)
+	}
+	return cmd
+}
+
+// nodeFit describes whether a job fits on a node.
+type nodeFit struct {
+	nodeUtilization
+	Cluster string `json:"cluster"`
+	Fits    bool   `json:"fits"`
+	Reason  string `json:"reason,omitempty"`
+}
+
 func newClusterGetCommand() *cobra.Command {
 	return &cobra.Command{
 		Use:     "get <cluster...>",
@@ -255,29 +482,242 @@ func newClusterNodesCommand() *cobra.Command {
 	}
 }
 
+func newClusterQueueCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "queue <cluster>",
+		Short: "List jobs waiting to be scheduled on a cluster",
+		Long: `List jobs waiting to be scheduled on a cluster.
+
+Jobs are ordered by priority and then by age, which approximates the order in
+which they will be scheduled. For your own jobs, the reasons they can't be
+placed on each node are also shown along with the number of jobs ahead which
+compete for the same nodes.
+
+The wait for your jobs is estimated from the jobs running on the nodes they
+can use, assuming each runs for as long again as it has so far and a queued
+job starts whenever one finishes. It's a rough guide; no estimate is shown
+when too few jobs are running to make one.`,
+		Args: cobra.ExactArgs(1),
+	}
+
+	cmd.RunE = func(cmd *cobra.Command, args []string) error {
+		cluster := args[0]
+		jobs, err := listJobs(client.ListJobOpts{
+			Cluster:   cluster,
+			Scheduled: api.BoolPtr(false),
+			Finalized: api.BoolPtr(false),
+		})
+		if err != nil {
+			return err
+		}
+
+		queue := make([]queuedJob, len(jobs))
+		for i, job := range jobs {
+			queue[i] = queuedJob{Job: job, Priority: jobPriority(job)}
+		}
+		sort.SliceStable(queue, func(i, j int) bool {
+			a, b := priorityRank(queue[i].Priority), priorityRank(queue[j].Priority)
+			if a != b {
+				return a < b
+			}
+			return queue[i].Status.Created.Before(queue[j].Status.Created)
+		})
+		for i := range queue {
+			queue[i].Position = i + 1
+		}
+
+		user, err := beaker.WhoAmI(ctx)
+		if err != nil {
+			return err
+		}
+		var nodes []nodeUtilization
+		var running []api.Job
+		for i, job := range queue {
+			if job.Author.ID != user.ID {
+				continue
+			}
+			if nodes == nil {
+				if nodes, err = getNodeUtilization(cluster, ""); err != nil {
+					return err
+				}
+				if running, err = listJobs(client.ListJobOpts{
+					Cluster:   cluster,
+					Scheduled: api.BoolPtr(true),
+					Finalized: api.BoolPtr(false),
+				}); err != nil {
+					return err
+				}
+			}
+
+			// Nodes are candidates if they could run the job once empty.
+			candidates := make(map[string]bool)
+			queue[i].Blockers = make(map[string]string)
+			for _, node := range nodes {
+				if !canRunOn(job.Job, node.Node) {
+					continue
+				}
+				candidates[node.ID] = true
+				if err := checkNodeCapacity(node.freeNode(), job.Requests, nil); err != nil {
+					queue[i].Blockers[node.Hostname] = err.Error()
+				} else {
+					queue[i].FitsOn = append(queue[i].FitsOn, node.Hostname)
+				}
+			}
+
+			ahead := 0
+			for _, other := range queue[:i] {
+				for _, node := range nodes {
+					if candidates[node.ID] && canRunOn(other.Job, node.Node) {
+						ahead++
+						break
+					}
+				}
+			}
+			queue[i].Ahead = &ahead
+			queue[i].EstimatedWait = estimateQueueWait(running, candidates, ahead, len(queue[i].FitsOn) > 0)
+		}
+		return printClusterQueue(queue)
+	}
+	return cmd
+}
+
+// queuedJob is a job waiting to be scheduled.
+type queuedJob struct {
+	api.Job
+
+	// Estimated position in the queue, starting at 1.
+	Position int          `json:"position"`
+	Priority api.Priority `json:"priority,omitempty"`
+
+	// Nodes with enough free resources for the job and reasons the job
+	// can't be placed on the others, keyed by hostname. Only calculated for
+	// the current user's jobs, as are the fields below.
+	FitsOn   []string          `json:"fitsOn,omitempty"`
+	Blockers map[string]string `json:"blockers,omitempty"`
+
+	// Number of jobs ahead in the queue which can run on the same nodes.
+	Ahead *int `json:"ahead,omitempty"`
+
+	// Estimated time until the job is scheduled. Nil if it can't be estimated.
+	EstimatedWait *time.Duration `json:"estimatedWait,omitempty"`
+}
+
+// canRunOn returns whether a job could run on a node once the node is empty.
+func canRunOn(job api.Job, node api.Node) bool {
+	// Sessions can only run on the node they were created for.
+	if job.Kind == api.JobKindSession && job.Node != "" && job.Node != node.ID {
+		return false
+	}
+	return checkNodeCapacity(&node, job.Requests, nil) == nil
+}
+
+// estimateQueueWait estimates how long a queued job will wait for one of the
+// candidate nodes. Each running job on those nodes is assumed to run for as
+// long again as it has so far, and one queued job starts as each finishes, so
+// the job starts when the (ahead+1)th running job finishes. Returns nil if
+// fewer jobs are running than that.
+func estimateQueueWait(running []api.Job, candidates map[string]bool, ahead int, fitsNow bool) *time.Duration {
+	if fitsNow && ahead == 0 {
+		var wait time.Duration
+		return &wait
+	}
+
+	var remaining []time.Duration
+	for _, job := range running {
+		if candidates[job.Node] && job.Status.Started != nil {
+			remaining = append(remaining, time.Since(*job.Status.Started))
+		}
+	}
+	if len(remaining) <= ahead {
+		return nil
+	}
+	sort.Slice(remaining, func(i, j int) bool { return remaining[i] < remaining[j] })
+	wait := remaining[ahead]
+	return &wait
+}
+
+// jobPriority returns the priority of an execution. Sessions have no priority.
+func jobPriority(job api.Job) api.Priority {
+	if job.Execution == nil {
+		return ""
+	}
+	if job.Execution.Spec.Context.Priority == "" {
+		return api.NormalPriority
+	}
+	return job.Execution.Spec.Context.Priority
+}
+
+// priorityRank orders priorities from most to least urgent. Jobs without a
+// priority are ranked as normal.
+func priorityRank(priority api.Priority) int {
+	switch priority {
+	case api.UrgentPriority:
+		return 0
+	case api.HighPriority:
+		return 1
+	case api.LowPriority:
+		return 3
+	case api.PreemptiblePriority:
+		return 4
+	default:
+		return 2
+	}
+}
+
+// queueAge returns how long a job has been waiting.
+func queueAge(job api.Job) time.Duration {
+	return time.Since(job.Status.Created)
+}
+
 func newClusterUpdateCommand() *cobra.Command {
 	cmd := &cobra.Command{
 		Use:   "update <cluster>",
 		Short: "Modify a cluster",
-		Args:  cobra.ExactArgs(1),
+		Long: `Modify a cluster.
+
+Only the maximum size of a cluster can be changed. To change the node shape or
+preemptibility of a cloud cluster, create a new cluster.`,
+		Args: cobra.ExactArgs(1),
 	}
 
 	var maxSize int
+	var dryRun bool
 	cmd.Flags().IntVar(&maxSize, "max-size", -1, "Maximum number of nodes")
+	cmd.Flags().BoolVar(&dryRun, "dry-run", false, "Show changes without applying them")
 
 	cmd.RunE = func(cmd *cobra.Command, args []string) error {
-		patch := api.ClusterPatch{}
-		if maxSize >= 0 {
-			patch.Capacity = &maxSize
+		if !cmd.Flags().Changed("max-size") {
+			fmt.Println("Nothing to update.")
+			return nil
+		}
+		if maxSize < 0 {
+			return fmt.Errorf("max size must not be negative: %d", maxSize)
 		}
 
-		if (patch == api.ClusterPatch{}) {
+		cluster, err := beaker.Cluster(args[0]).Get(ctx)
+		if err != nil {
+			return err
+		}
+		before := api.ClusterSpec{
+			Name:        cluster.FullName,
+			Capacity:    cluster.Capacity,
+			Preemptible: cluster.Preemptible,
+			Spec:        &cluster.NodeSpec,
+		}
+		after := before
+		after.Capacity = maxSize
+		if after.Capacity == before.Capacity {
 			fmt.Println("Nothing to update.")
 			return nil
 		}
 
-		cluster, err := beaker.Cluster(args[0]).Patch(ctx, &patch)
-		if err != nil {
+		if dryRun {
+			printClusterSpecDiff(before, after)
+			return nil
+		}
+
+		patch := api.ClusterPatch{Capacity: &maxSize}
+		if cluster, err = beaker.Cluster(args[0]).Patch(ctx, &patch); err != nil {
 			return err
 		}
 
@@ -0,0 +1,249 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/beaker/client/api"
+	"github.com/beaker/client/client"
+	"github.com/spf13/cobra"
+)
+
+// jobCostGroup is the estimated cost of a group of jobs.
+type jobCostGroup struct {
+	Group    string  `json:"group"`
+	Jobs     int     `json:"jobs"`
+	GPUHours float64 `json:"gpuHours"`
+	Cost     float64 `json:"cost"` // USD
+}
+
+// costFlags are shared by commands which estimate job costs.
+type costFlags struct {
+	since   string
+	groupBy string
+}
+
+func addCostFlags(cmd *cobra.Command, defaultGroupBy string) *costFlags {
+	flags := &costFlags{}
+	cmd.Flags().StringVar(
+		&flags.since,
+		"since",
+		"30d",
+		"Start of the reporting period as a duration e.g. 30d, 12h or a date e.g. 2022-01-31")
+	cmd.Flags().StringVar(
+		&flags.groupBy,
+		"group-by",
+		defaultGroupBy,
+		"Group costs by one of: author, experiment, workspace")
+	return flags
+}
+
+// parseSince parses the start of a reporting period, which is either a
+// duration before now or a date. Durations may be in days e.g. "30d".
+func parseSince(since string) (time.Time, error) {
+	if t, err := time.ParseInLocation("2006-01-02", since, time.Local); err == nil {
+		return t, nil
+	}
+	if strings.HasSuffix(since, "d") {
+		days, err := strconv.Atoi(strings.TrimSuffix(since, "d"))
+		if err != nil {
+			return time.Time{}, fmt.Errorf("invalid value for --since: %s", since)
+		}
+		return time.Now().AddDate(0, 0, -days), nil
+	}
+	d, err := time.ParseDuration(since)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid value for --since: %s", since)
+	}
+	return time.Now().Add(-d), nil
+}
+
+// listClusterJobsSince lists jobs in a cluster which ran at any point after
+// the given time.
+func listClusterJobsSince(cluster string, since time.Time) ([]api.Job, error) {
+	jobs, err := listJobs(client.ListJobOpts{
+		Cluster:   cluster,
+		Finalized: api.BoolPtr(false),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	// Finalized jobs are listed newest first until they're out of range.
+	opts := client.ListJobOpts{
+		Cluster:   cluster,
+		Finalized: api.BoolPtr(true),
+		SortBy:    api.JobFinalized,
+		Order:     api.SortDescending,
+	}
+	for {
+		page, err := beaker.ListJobs(ctx, &opts)
+		if err != nil {
+			return nil, err
+		}
+		for _, job := range page.Data {
+			if job.Status.Finalized != nil && job.Status.Finalized.Before(since) {
+				return jobs, nil
+			}
+			jobs = append(jobs, job)
+		}
+		if page.Next == "" {
+			return jobs, nil
+		}
+		opts.Cursor = page.Next
+	}
+}
+
+// costEstimator estimates the cost of jobs from the hourly cost of the nodes
+// they ran on. Clusters and nodes are cached between jobs.
+type costEstimator struct {
+	since    time.Time
+	clusters map[string]*api.Cluster
+	nodes    map[string]map[string]api.Node // By cluster, then node ID.
+}
+
+func newCostEstimator(since time.Time) *costEstimator {
+	return &costEstimator{
+		since:    since,
+		clusters: make(map[string]*api.Cluster),
+		nodes:    make(map[string]map[string]api.Node),
+	}
+}
+
+// estimate returns a job's cost in USD and its GPU hours since the start of
+// the reporting period. Jobs are charged for the largest share of CPUs, GPUs
+// or memory they reserve on their node. Jobs on clusters without a node cost
+// e.g. on-premise clusters are free.
+func (e *costEstimator) estimate(job api.Job) (cost float64, gpuHours float64, err error) {
+	if job.Status.Scheduled == nil || job.Limits == nil || job.Cluster == "" {
+		return 0, 0, nil
+	}
+
+	duration := jobDuration(job)
+	if job.Status.Scheduled.Before(e.since) {
+		duration -= e.since.Sub(*job.Status.Scheduled)
+	}
+	if duration <= 0 {
+		return 0, 0, nil
+	}
+	hours := duration.Hours()
+	gpuHours = hours * float64(len(job.Limits.GPUs))
+
+	cluster, ok := e.clusters[job.Cluster]
+	if !ok {
+		if cluster, err = beaker.Cluster(job.Cluster).Get(ctx); err != nil {
+			return 0, 0, fmt.Errorf("getting cluster %s: %w", job.Cluster, err)
+		}
+		e.clusters[job.Cluster] = cluster
+	}
+	if cluster.NodeCost == nil {
+		return 0, gpuHours, nil
+	}
+	nodeCost, _ := cluster.NodeCost.Float64()
+
+	nodes, ok := e.nodes[job.Cluster]
+	if !ok {
+		list, err := beaker.Cluster(job.Cluster).ListClusterNodes(ctx)
+		if err != nil {
+			return 0, 0, fmt.Errorf("listing nodes in %s: %w", job.Cluster, err)
+		}
+		nodes = make(map[string]api.Node, len(list))
+		for _, node := range list {
+			nodes[node.ID] = node
+		}
+		e.nodes[job.Cluster] = nodes
+	}
+
+	// Nodes of cloud clusters come and go, so fall back to the cluster's
+	// node shape when the job's node no longer exists.
+	shape := cluster.NodeShape
+	if node, ok := nodes[job.Node]; ok && node.Limits != nil {
+		shape = node.Limits
+	}
+	return hours * nodeCost * nodeShare(job.Limits, shape), gpuHours, nil
+}
+
+// nodeShare returns the largest fraction of a node's CPUs, GPUs or memory
+// reserved by a job. Jobs are charged for the whole node if its shape is
+// unknown.
+func nodeShare(limits *api.ResourceLimits, node *api.NodeResources) float64 {
+	if node == nil {
+		return 1
+	}
+	var share float64
+	if node.CPUCount > 0 {
+		share = limits.CPUCount / node.CPUCount
+	}
+	if node.GPUCount > 0 {
+		if s := float64(len(limits.GPUs)) / float64(node.GPUCount); s > share {
+			share = s
+		}
+	}
+	if node.Memory != nil && limits.Memory != nil && !node.Memory.IsZero() {
+		if s := float64(limits.Memory.Int64()) / float64(node.Memory.Int64()); s > share {
+			share = s
+		}
+	}
+	if share > 1 {
+		share = 1
+	}
+	return share
+}
+
+// groupJobCosts estimates the cost of jobs grouped by author, experiment or
+// workspace. Groups are sorted by descending cost.
+func groupJobCosts(jobs []api.Job, since time.Time, groupBy string) ([]jobCostGroup, error) {
+	var key func(api.Job) string
+	switch groupBy {
+	case "author":
+		key = func(job api.Job) string { return job.Author.Name }
+	case "experiment":
+		key = func(job api.Job) string {
+			if job.Execution == nil {
+				return "(sessions)"
+			}
+			return job.Execution.Experiment
+		}
+	case "workspace":
+		key = func(job api.Job) string { return job.Workspace }
+	default:
+		return nil, fmt.Errorf("invalid value for --group-by: %s", groupBy)
+	}
+
+	estimator := newCostEstimator(since)
+	groups := make(map[string]*jobCostGroup)
+	for _, job := range jobs {
+		if job.Status.Finalized != nil && job.Status.Finalized.Before(since) {
+			continue
+		}
+		cost, gpuHours, err := estimator.estimate(job)
+		if err != nil {
+			return nil, err
+		}
+
+		k := key(job)
+		group, ok := groups[k]
+		if !ok {
+			group = &jobCostGroup{Group: k}
+			groups[k] = group
+		}
+		group.Jobs++
+		group.Cost += cost
+		group.GPUHours += gpuHours
+	}
+
+	result := make([]jobCostGroup, 0, len(groups))
+	for _, group := range groups {
+		result = append(result, *group)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		if result[i].Cost != result[j].Cost {
+			return result[i].Cost > result[j].Cost
+		}
+		return result[i].Group < result[j].Group
+	})
+	return result, nil
+}
@@ -0,0 +1,191 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/fatih/color"
+)
+
+// diffLine is a line of a line-by-line diff. Op is ' ' for a line in both
+// inputs, '-' for a line only in the first and '+' for a line only in the
+// second.
+type diffLine struct {
+	Op   byte
+	Text string
+}
+
+// diffLines computes a minimal line diff from the longest common subsequence
+// of a and b. It's quadratic, which is fine for specs and config files.
+func diffLines(a, b []string) []diffLine {
+	// lcs[i][j] is the length of the longest common subsequence of a[i:], b[j:].
+	lcs := make([][]int, len(a)+1)
+	for i := range lcs {
+		lcs[i] = make([]int, len(b)+1)
+	}
+	for i := len(a) - 1; i >= 0; i-- {
+		for j := len(b) - 1; j >= 0; j-- {
+			if a[i] == b[j] {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else if lcs[i+1][j] >= lcs[i][j+1] {
+				lcs[i][j] = lcs[i+1][j]
+			} else {
+				lcs[i][j] = lcs[i][j+1]
+			}
+		}
+	}
+
+	var lines []diffLine
+	i, j := 0, 0
+	for i < len(a) && j < len(b) {
+		switch {
+		case a[i] == b[j]:
+			lines = append(lines, diffLine{' ', a[i]})
+			i++
+			j++
+		case lcs[i+1][j] >= lcs[i][j+1]:
+			lines = append(lines, diffLine{'-', a[i]})
+			i++
+		default:
+			lines = append(lines, diffLine{'+', b[j]})
+			j++
+		}
+	}
+	for ; i < len(a); i++ {
+		lines = append(lines, diffLine{'-', a[i]})
+	}
+	for ; j < len(b); j++ {
+		lines = append(lines, diffLine{'+', b[j]})
+	}
+	return lines
+}
+
+// hasChanges returns whether a diff contains any added or removed lines.
+func hasChanges(lines []diffLine) bool {
+	for _, line := range lines {
+		if line.Op != ' ' {
+			return true
+		}
+	}
+	return false
+}
+
+// printUnifiedDiff prints a diff in unified format with the given number of
+// lines of context around each change.
+func printUnifiedDiff(w io.Writer, aName, bName string, lines []diffLine, contextLines int) {
+	fmt.Fprintln(w, color.RedString("--- "+aName))
+	fmt.Fprintln(w, color.GreenString("+++ "+bName))
+
+	// Line numbers in a and b at the start of each diff line.
+	aLine := make([]int, len(lines)+1)
+	bLine := make([]int, len(lines)+1)
+	aLine[0], bLine[0] = 1, 1
+	for k, line := range lines {
+		aLine[k+1], bLine[k+1] = aLine[k], bLine[k]
+		if line.Op != '+' {
+			aLine[k+1]++
+		}
+		if line.Op != '-' {
+			bLine[k+1]++
+		}
+	}
+
+	for k := 0; k < len(lines); {
+		if lines[k].Op == ' ' {
+			k++
+			continue
+		}
+
+		// Extend the hunk until a run of unchanged lines is too long to join
+		// with the next change.
+		start := k - contextLines
+		if start < 0 {
+			start = 0
+		}
+		end := k
+		for end < len(lines) {
+			if lines[end].Op != ' ' {
+				end++
+				continue
+			}
+			run := end
+			for run < len(lines) && lines[run].Op == ' ' {
+				run++
+			}
+			if run == len(lines) || run-end > 2*contextLines {
+				end += contextLines
+				if end > len(lines) {
+					end = len(lines)
+				}
+				break
+			}
+			end = run
+		}
+
+		aCount := aLine[end] - aLine[start]
+		bCount := bLine[end] - bLine[start]
+		fmt.Fprintln(w, color.CyanString("@@ -%d,%d +%d,%d @@", aLine[start], aCount, bLine[start], bCount))
+		for _, line := range lines[start:end] {
+			switch line.Op {
+			case '-':
+				fmt.Fprintln(w, color.RedString("-"+line.Text))
+			case '+':
+				fmt.Fprintln(w, color.GreenString("+"+line.Text))
+			default:
+				fmt.Fprintln(w, " "+line.Text)
+			}
+		}
+		k = end
+	}
+}
+
+// printSideBySideDiff prints a diff in two columns, marking changed lines with
+// '|', removed lines with '<' and added lines with '>'.
+func printSideBySideDiff(w io.Writer, lines []diffLine, width int) {
+	column := (width - 3) / 2
+	if column < 10 {
+		column = 10
+	}
+	row := func(left, mark, right string) {
+		fmt.Fprintf(w, "%-*s %s %s\n", column, truncate(left, column), mark, truncate(right, column))
+	}
+
+	for k := 0; k < len(lines); {
+		if lines[k].Op == ' ' {
+			row(lines[k].Text, " ", lines[k].Text)
+			k++
+			continue
+		}
+
+		// Pair each block of removed lines with the added lines after it.
+		var removed, added []string
+		for ; k < len(lines) && lines[k].Op == '-'; k++ {
+			removed = append(removed, lines[k].Text)
+		}
+		for ; k < len(lines) && lines[k].Op == '+'; k++ {
+			added = append(added, lines[k].Text)
+		}
+		for n := 0; n < len(removed) || n < len(added); n++ {
+			switch {
+			case n < len(removed) && n < len(added):
+				row(removed[n], color.YellowString("|"), added[n])
+			case n < len(removed):
+				row(removed[n], color.RedString("<"), "")
+			default:
+				row("", color.GreenString(">"), added[n])
+			}
+		}
+	}
+}
+
+func truncate(s string, n int) string {
+	s = strings.ReplaceAll(s, "\t", "    ")
+	if len(s) <= n {
+		return s
+	}
+	if n <= 3 {
+		return s[:n]
+	}
+	return s[:n-3] + "..."
+}
@@ -36,6 +36,20 @@ type executorConfig struct {
 
 	// (optional) List of allowed mount paths for this machine.
 	MountPaths []string `yaml:"mountPaths"`
+
+	// Connection to Beaker.
+	Beaker executorBeakerConfig `yaml:"beaker"`
+}
+
+type executorBeakerConfig struct {
+	// Address of the Beaker service.
+	Address string `yaml:"address"`
+
+	// Path to a file containing the executor's Beaker token.
+	TokenPath string `yaml:"tokenPath"`
+
+	// Cluster that the executor's node belongs to.
+	Cluster string `yaml:"cluster"`
 }
 
 // Get the config of the executor running on this machine.
@@ -44,6 +58,11 @@ func getExecutorConfig() (*executorConfig, error) {
 	if err != nil {
 		return nil, err
 	}
+	return parseExecutorConfig(configFile)
+}
+
+// Parse executor config, expanding environment variables.
+func parseExecutorConfig(configFile []byte) (*executorConfig, error) {
 	expanded := strings.NewReader(os.ExpandEnv(string(configFile)))
 
 	var config executorConfig
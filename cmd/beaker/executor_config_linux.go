@@ -0,0 +1,360 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"gopkg.in/yaml.v3"
+)
+
+func newExecutorConfigCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "config <command>",
+		Short: "Manage the executor's configuration",
+		Long: fmt.Sprintf(`Manage the executor's configuration in %s.
+
+Keys are paths of YAML fields separated by dots, e.g. "beaker.cluster".
+Changes take effect when the executor is restarted.`, executorConfigPath),
+	}
+	cmd.AddCommand(newExecutorConfigEditCommand())
+	cmd.AddCommand(newExecutorConfigGetCommand())
+	cmd.AddCommand(newExecutorConfigSetCommand())
+	cmd.AddCommand(newExecutorConfigUnsetCommand())
+	return cmd
+}
+
+func newExecutorConfigEditCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "edit",
+		Short: "Edit the executor's configuration with $EDITOR",
+		Args:  cobra.NoArgs,
+	}
+
+	var restart bool
+	cmd.Flags().BoolVar(&restart, "restart", false, "Restart the executor to apply changes")
+
+	cmd.RunE = func(cmd *cobra.Command, args []string) error {
+		original, err := ioutil.ReadFile(executorConfigPath)
+		if err != nil {
+			return err
+		}
+
+		tmp, err := ioutil.TempFile("", "executor-config-*.yml")
+		if err != nil {
+			return err
+		}
+		defer os.Remove(tmp.Name())
+		if _, err := tmp.Write(original); err != nil {
+			return err
+		}
+		if err := tmp.Close(); err != nil {
+			return err
+		}
+
+		editor := os.Getenv("EDITOR")
+		if editor == "" {
+			editor = "vi"
+		}
+
+		var edited []byte
+		for {
+			c := exec.CommandContext(ctx, editor, tmp.Name())
+			c.Stdin, c.Stdout, c.Stderr = os.Stdin, os.Stdout, os.Stderr
+			if err := c.Run(); err != nil {
+				return fmt.Errorf("running editor: %w", err)
+			}
+
+			if edited, err = ioutil.ReadFile(tmp.Name()); err != nil {
+				return err
+			}
+			err := validateExecutorConfig(edited)
+			if err == nil {
+				break
+			}
+
+			fmt.Println("Invalid config:", err)
+			again, err := confirm("Edit again?")
+			if err != nil {
+				return err
+			}
+			if !again {
+				return errors.New("config not saved")
+			}
+		}
+
+		if bytes.Equal(original, edited) {
+			if !quiet {
+				fmt.Println("Config unchanged")
+			}
+			return nil
+		}
+		return saveExecutorConfig(edited, restart)
+	}
+	return cmd
+}
+
+func newExecutorConfigGetCommand() *cobra.Command {
+	return &cobra.Command{
+		Use:   "get [key]",
+		Short: "Print the executor's configuration or a single value",
+		Args:  cobra.MaximumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			doc, err := readExecutorConfigNode()
+			if err != nil {
+				return err
+			}
+
+			node := doc
+			if len(args) > 0 {
+				if node = findYAMLNode(doc.Content[0], args[0]); node == nil {
+					return fmt.Errorf("%s is not set", args[0])
+				}
+				if node.Kind == yaml.ScalarNode {
+					fmt.Println(node.Value)
+					return nil
+				}
+			}
+
+			out, err := encodeYAML(node)
+			if err != nil {
+				return err
+			}
+			fmt.Print(string(out))
+			return nil
+		},
+	}
+}
+
+func newExecutorConfigSetCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "set <key> <value>",
+		Short: "Set a value in the executor's configuration",
+		Long: `Set a value in the executor's configuration.
+
+Values are parsed as YAML, e.g. to set a list:
+
+    beaker executor config set mountPaths '[/data, /net/nfs]'`,
+		Args: cobra.ExactArgs(2),
+	}
+
+	var restart bool
+	cmd.Flags().BoolVar(&restart, "restart", false, "Restart the executor to apply changes")
+
+	cmd.RunE = func(cmd *cobra.Command, args []string) error {
+		doc, err := readExecutorConfigNode()
+		if err != nil {
+			return err
+		}
+
+		var value yaml.Node
+		if err := yaml.Unmarshal([]byte(args[1]), &value); err != nil {
+			return fmt.Errorf("invalid value: %w", err)
+		}
+		if len(value.Content) == 0 {
+			// An empty string parses as an empty document.
+			value.Content = []*yaml.Node{{Kind: yaml.ScalarNode, Tag: "!!str"}}
+		}
+		setYAMLNode(doc.Content[0], args[0], value.Content[0])
+
+		out, err := encodeYAML(doc)
+		if err != nil {
+			return err
+		}
+		if err := validateExecutorConfig(out); err != nil {
+			return fmt.Errorf("invalid config: %w", err)
+		}
+		return saveExecutorConfig(out, restart)
+	}
+	return cmd
+}
+
+func newExecutorConfigUnsetCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "unset <key>",
+		Short: "Remove a value from the executor's configuration",
+		Args:  cobra.ExactArgs(1),
+	}
+
+	var restart bool
+	cmd.Flags().BoolVar(&restart, "restart", false, "Restart the executor to apply changes")
+
+	cmd.RunE = func(cmd *cobra.Command, args []string) error {
+		doc, err := readExecutorConfigNode()
+		if err != nil {
+			return err
+		}
+		if !unsetYAMLNode(doc.Content[0], args[0]) {
+			return fmt.Errorf("%s is not set", args[0])
+		}
+
+		out, err := encodeYAML(doc)
+		if err != nil {
+			return err
+		}
+		if err := validateExecutorConfig(out); err != nil {
+			return fmt.Errorf("invalid config: %w", err)
+		}
+		return saveExecutorConfig(out, restart)
+	}
+	return cmd
+}
+
+// readExecutorConfigNode reads the executor's config as a YAML document,
+// which preserves comments and fields unknown to this command.
+func readExecutorConfigNode() (*yaml.Node, error) {
+	configFile, err := ioutil.ReadFile(executorConfigPath)
+	if err != nil {
+		return nil, err
+	}
+
+	var doc yaml.Node
+	if err := yaml.Unmarshal(configFile, &doc); err != nil {
+		return nil, fmt.Errorf("parsing %s: %w", executorConfigPath, err)
+	}
+	if len(doc.Content) == 0 {
+		doc = yaml.Node{
+			Kind:    yaml.DocumentNode,
+			Content: []*yaml.Node{{Kind: yaml.MappingNode, Tag: "!!map"}},
+		}
+	}
+	if doc.Content[0].Kind != yaml.MappingNode {
+		return nil, fmt.Errorf("%s must contain a YAML mapping", executorConfigPath)
+	}
+	return &doc, nil
+}
+
+// setYAMLNode sets the value at a dotted key within a mapping, creating
+// intermediate mappings as needed. Comments on a replaced value are kept.
+func setYAMLNode(node *yaml.Node, key string, value *yaml.Node) {
+	keys := strings.Split(key, ".")
+	for i, k := range keys {
+		if node.Kind != yaml.MappingNode {
+			// Replace scalars and sequences in the way with a mapping.
+			*node = yaml.Node{Kind: yaml.MappingNode, Tag: "!!map", HeadComment: node.HeadComment}
+		}
+
+		var next *yaml.Node
+		for j := 0; j+1 < len(node.Content); j += 2 {
+			if node.Content[j].Value == k {
+				next = node.Content[j+1]
+				break
+			}
+		}
+		if next == nil {
+			next = &yaml.Node{Kind: yaml.MappingNode, Tag: "!!map"}
+			node.Content = append(node.Content,
+				&yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: k},
+				next)
+		}
+
+		if i == len(keys)-1 {
+			value.HeadComment = next.HeadComment
+			value.LineComment = next.LineComment
+			value.FootComment = next.FootComment
+			*next = *value
+			return
+		}
+		node = next
+	}
+}
+
+// unsetYAMLNode removes the value at a dotted key. Returns false if the key
+// is not set.
+func unsetYAMLNode(node *yaml.Node, key string) bool {
+	keys := strings.Split(key, ".")
+	parent := findYAMLNode(node, strings.Join(keys[:len(keys)-1], "."))
+	if len(keys) == 1 {
+		parent = node
+	}
+	if parent == nil || parent.Kind != yaml.MappingNode {
+		return false
+	}
+
+	last := keys[len(keys)-1]
+	for i := 0; i+1 < len(parent.Content); i += 2 {
+		if parent.Content[i].Value == last {
+			parent.Content = append(parent.Content[:i], parent.Content[i+2:]...)
+			return true
+		}
+	}
+	return false
+}
+
+// validateExecutorConfig checks that config can be read by the executor.
+func validateExecutorConfig(configFile []byte) error {
+	config, err := parseExecutorConfig(configFile)
+	if err != nil {
+		return err
+	}
+
+	if config.StoragePath != "" && !path.IsAbs(config.StoragePath) {
+		return fmt.Errorf("storagePath must be an absolute path: %s", config.StoragePath)
+	}
+	if config.SessionHome != "" && !path.IsAbs(config.SessionHome) {
+		return fmt.Errorf("sessionHome must be an absolute path: %s", config.SessionHome)
+	}
+	for _, p := range config.MountPaths {
+		if !path.IsAbs(p) {
+			return fmt.Errorf("mountPaths must be absolute paths: %s", p)
+		}
+	}
+	if config.Beaker.Cluster == "" {
+		return errors.New("beaker.cluster is required")
+	}
+	return nil
+}
+
+// saveExecutorConfig atomically replaces the executor's config file and
+// optionally restarts the executor to apply it.
+func saveExecutorConfig(configFile []byte, restart bool) error {
+	mode := os.FileMode(0644)
+	if info, err := os.Stat(executorConfigPath); err == nil {
+		mode = info.Mode().Perm()
+	}
+
+	tmp, err := ioutil.TempFile(filepath.Dir(executorConfigPath), ".config-*.yml")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	defer tmp.Close()
+
+	if _, err := tmp.Write(configFile); err != nil {
+		return err
+	}
+	if err := tmp.Chmod(mode); err != nil {
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	if err := os.Rename(tmp.Name(), executorConfigPath); err != nil {
+		return err
+	}
+
+	if !restart {
+		if !quiet {
+			fmt.Println(`Config saved. Run "beaker executor restart" to apply changes.`)
+		}
+		return nil
+	}
+
+	if err := stopExecutor(); err != nil {
+		return err
+	}
+	if err := startExecutor(); err != nil {
+		return err
+	}
+	if !quiet {
+		fmt.Println("Config saved and executor restarted")
+	}
+	return nil
+}
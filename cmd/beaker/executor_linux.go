@@ -2,6 +2,9 @@ package main
 
 import (
 	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
 	"fmt"
 	"io"
 	"io/ioutil"
@@ -9,19 +12,32 @@ import (
 	"os"
 	"os/exec"
 	"path"
+	"strconv"
 	"strings"
+	"syscall"
 	"text/template"
 	"time"
 
+	"github.com/allenai/bytefmt"
+	"github.com/beaker/runtime"
+	"github.com/beaker/runtime/docker"
+	"github.com/fatih/color"
 	"github.com/spf13/cobra"
 )
 
 const (
+	// Default location of executor releases. Mirrors must have the same layout.
+	defaultExecutorMirror = "https://storage.googleapis.com/ai2-beaker-public/bin"
+
 	// The version URL must respond to a GET request with the latest version of the executor.
-	versionURL = "https://storage.googleapis.com/ai2-beaker-public/bin/latest"
+	// Replace %s with the mirror.
+	versionURL = "%s/latest"
+
+	// Replace the first %s with the mirror and the second with the version from the URL above.
+	executorURL = "%s/%s/executor"
 
-	// Replace %s with the version from the URL above.
-	executorURL = "https://storage.googleapis.com/ai2-beaker-public/bin/%s/executor"
+	// Suffix of the file containing the SHA-256 digest of each executor binary.
+	executorDigestSuffix = ".sha256"
 
 	// Path to the executor binary.
 	executorPath = "/usr/bin/beaker-executor"
@@ -36,8 +52,30 @@ var (
 
 	// Path where the Systemd configuration file for the executor is stored.
 	executorSystemdPath = fmt.Sprintf("/etc/systemd/system/%s.service", executorService)
+
+	// Path where the installed executor version is recorded.
+	executorVersionPath = path.Join(executorConfigDir, "executor-version")
+
+	// Where the executor is installed on this machine.
+	defaultExecutorInstall = executorInstall{
+		binaryPath:  executorPath,
+		versionPath: executorVersionPath,
+	}
 )
 
+// executorInstall locates an installed executor binary and the file recording
+// its version. The files they replace are kept alongside with a ".prev" suffix.
+type executorInstall struct {
+	binaryPath  string
+	versionPath string
+}
+
+// prevBinaryPath is the binary replaced by the last install or upgrade.
+func (i executorInstall) prevBinaryPath() string { return i.binaryPath + ".prev" }
+
+// prevVersionPath records the version of the previous binary.
+func (i executorInstall) prevVersionPath() string { return i.versionPath + ".prev" }
+
 var configTemplate = template.Must(template.New("config").Parse(`
 storagePath: {{.StoragePath}}
 beaker:
@@ -81,10 +119,14 @@ func newExecutorCommand() *cobra.Command {
 		Use:   "executor <command>",
 		Short: "Manage the executor",
 	}
+	cmd.AddCommand(newExecutorConfigCommand())
 	cmd.AddCommand(newExecutorConfigureCommand())
+	cmd.AddCommand(newExecutorDoctorCommand())
 	cmd.AddCommand(newExecutorInstallCommand())
 	cmd.AddCommand(newExecutorRestartCommand())
+	cmd.AddCommand(newExecutorRollbackCommand())
 	cmd.AddCommand(newExecutorStartCommand())
+	cmd.AddCommand(newExecutorStatusCommand())
 	cmd.AddCommand(newExecutorStopCommand())
 	cmd.AddCommand(newExecutorUninstallCommand())
 	cmd.AddCommand(newExecutorUpgradeCommand())
@@ -148,6 +190,65 @@ func newExecutorConfigureCommand() *cobra.Command {
 	return cmd
 }
 
+func newExecutorDoctorCommand() *cobra.Command {
+	return &cobra.Command{
+		Use:   "doctor",
+		Short: "Check the executor's installation for common problems",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			var failed int
+			check := func(name string, err error) {
+				if err != nil {
+					failed++
+					fmt.Printf("%s %s: %v\n", color.RedString("FAIL"), name, err)
+				} else if !quiet {
+					fmt.Printf("%s %s\n", color.GreenString("PASS"), name)
+				}
+			}
+
+			config, err := getExecutorConfig()
+			check("Config file "+executorConfigPath+" parses", err)
+			if err != nil {
+				// Every other check depends on the config.
+				return fmt.Errorf("%d checks failed", failed)
+			}
+
+			check("Executor service is active", checkExecutorActive())
+
+			tokenPath := config.Beaker.TokenPath
+			if tokenPath == "" {
+				tokenPath = executorTokenPath
+			}
+			check("Token file "+tokenPath+" is only readable by its owner", checkTokenFile(tokenPath))
+
+			storagePath, err := getExecutorStoragePath(config)
+			if err == nil {
+				err = checkWritable(storagePath)
+			}
+			check("Storage path "+storagePath+" is writable", err)
+
+			check("Docker is reachable", checkDocker())
+
+			if config.SessionHome != "" {
+				check("Session home "+config.SessionHome+" exists", checkDir(config.SessionHome))
+			}
+			for _, p := range config.MountPaths {
+				check("Mount path "+p+" exists", checkDir(p))
+			}
+
+			check("Node is registered with Beaker", checkNodeRegistration(config))
+
+			if failed > 0 {
+				return fmt.Errorf("%d checks failed", failed)
+			}
+			if !quiet {
+				fmt.Println("All checks passed")
+			}
+			return nil
+		},
+	}
+}
+
 func newExecutorInstallCommand() *cobra.Command {
 	cmd := &cobra.Command{
 		Use:   "install",
@@ -157,12 +258,7 @@ Requires access to /etc, /var, and /usr/bin. Also requires access to systemd.`,
 		Args: cobra.NoArgs,
 	}
 
-	var version string
-	cmd.Flags().StringVar(
-		&version,
-		"version",
-		"",
-		"Version of the Beaker executor. Commit SHA from allenai/beaker-service. Defaults to the latest version if empty.")
+	source := addExecutorSourceFlags(cmd)
 
 	var validate bool
 	cmd.Flags().BoolVar(
@@ -196,13 +292,7 @@ Run "configure" to generate a configuration file or write configuration to %s ma
 			return err
 		}
 
-		if version == "" {
-			version, err = getLatestVersion()
-			if err != nil {
-				return err
-			}
-		}
-		if err := downloadExecutor(version); err != nil {
+		if err := source.install(); err != nil {
 			return err
 		}
 
@@ -223,13 +313,9 @@ Run "configure" to generate a configuration file or write configuration to %s ma
 		if err != nil {
 			return err
 		}
-		storagePath := config.StoragePath
-		if storagePath == "" {
-			home, err := os.UserHomeDir()
-			if err != nil {
-				return err
-			}
-			storagePath = path.Join(home, ".beaker", "storage")
+		storagePath, err := getExecutorStoragePath(config)
+		if err != nil {
+			return err
 		}
 		ready := func(ctx context.Context) (bool, error) {
 			out, err := run("sudo", "systemctl", "is-active", executorService)
@@ -282,6 +368,48 @@ func newExecutorRestartCommand() *cobra.Command {
 	}
 }
 
+func newExecutorRollbackCommand() *cobra.Command {
+	return &cobra.Command{
+		Use:   "rollback",
+		Short: "Restore the executor replaced by the last install or upgrade",
+		Long: `Restore the executor binary replaced by the last install or upgrade.
+The current binary is kept, so running rollback again undoes the rollback.`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			install := defaultExecutorInstall
+			if _, err := os.Stat(install.prevBinaryPath()); os.IsNotExist(err) {
+				return fmt.Errorf("there is no previous executor to restore")
+			} else if err != nil {
+				return err
+			}
+
+			if err := stopExecutor(); err != nil {
+				return err
+			}
+
+			if err := swapFiles(install.binaryPath, install.prevBinaryPath()); err != nil {
+				return err
+			}
+			if err := swapFiles(install.versionPath, install.prevVersionPath()); err != nil {
+				return err
+			}
+
+			if err := startExecutor(); err != nil {
+				return err
+			}
+
+			if !quiet {
+				version := "unknown"
+				if b, err := ioutil.ReadFile(executorVersionPath); err == nil {
+					version = strings.TrimSpace(string(b))
+				}
+				fmt.Printf("Executor rolled back to version %s\n", version)
+			}
+			return nil
+		},
+	}
+}
+
 func newExecutorStartCommand() *cobra.Command {
 	return &cobra.Command{
 		Use:   "start",
@@ -300,6 +428,90 @@ func newExecutorStartCommand() *cobra.Command {
 	}
 }
 
+type executorStatus struct {
+	State        string        `json:"state"`
+	Version      string        `json:"version,omitempty"`
+	Node         string        `json:"node,omitempty"`
+	Cluster      string        `json:"cluster,omitempty"`
+	StoragePath  string        `json:"storagePath,omitempty"`
+	StorageUsed  *bytefmt.Size `json:"storageUsed,omitempty"`
+	StorageTotal *bytefmt.Size `json:"storageTotal,omitempty"`
+	Containers   *int          `json:"runningContainers,omitempty"`
+
+	// The executor extends its node's expiry with each heartbeat.
+	NodeExpiry *time.Time `json:"nodeExpiry,omitempty"`
+	LastLog    *time.Time `json:"lastLog,omitempty"`
+}
+
+func newExecutorStatusCommand() *cobra.Command {
+	return &cobra.Command{
+		Use:   "status",
+		Short: "Show the state of the executor on this machine",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			// Gather as much as we can; a broken executor is when status
+			// matters most.
+			status := executorStatus{State: getExecutorState()}
+
+			if version, err := ioutil.ReadFile(executorVersionPath); err == nil {
+				status.Version = strings.TrimSpace(string(version))
+			}
+
+			if config, err := getExecutorConfig(); err == nil {
+				status.Cluster = config.Beaker.Cluster
+				if storagePath, err := getExecutorStoragePath(config); err == nil {
+					status.StoragePath = storagePath
+					status.StorageUsed, status.StorageTotal, _ = diskUsage(storagePath)
+				}
+			}
+
+			if node, err := getCurrentNode(); err == nil {
+				status.Node = node
+				if n, err := beaker.Node(node).Get(ctx); err == nil {
+					status.NodeExpiry = n.Expiry
+				}
+			}
+
+			if containers, err := countRunningContainers(); err == nil {
+				status.Containers = &containers
+			}
+
+			status.LastLog = getExecutorLastLog()
+
+			switch format {
+			case formatJSON:
+				return printJSON(status)
+			default:
+				printTableRow("STATE", status.State)
+				printTableRow("VERSION", status.Version)
+				printTableRow("NODE", status.Node)
+				printTableRow("CLUSTER", status.Cluster)
+				printTableRow("STORAGE PATH", status.StoragePath)
+				var storage string
+				if status.StorageUsed != nil && status.StorageTotal != nil {
+					storage = fmt.Sprintf("%s used of %s", status.StorageUsed, status.StorageTotal)
+				}
+				printTableRow("STORAGE", storage)
+				var containers string
+				if status.Containers != nil {
+					containers = strconv.Itoa(*status.Containers)
+				}
+				printTableRow("RUNNING CONTAINERS", containers)
+				var expiry, lastLog time.Time
+				if status.NodeExpiry != nil {
+					expiry = *status.NodeExpiry
+				}
+				if status.LastLog != nil {
+					lastLog = *status.LastLog
+				}
+				printTableRow("NODE EXPIRY", expiry)
+				printTableRow("LAST LOG", lastLog)
+				return nil
+			}
+		},
+	}
+}
+
 func newExecutorStopCommand() *cobra.Command {
 	return &cobra.Command{
 		Use:   "stop",
@@ -382,8 +594,15 @@ Are you sure you want to uninstall the executor?`
 				return err
 			}
 
-			if err := os.Remove(executorPath); err != nil && !os.IsNotExist(err) {
-				return err
+			for _, p := range []string{
+				defaultExecutorInstall.binaryPath,
+				defaultExecutorInstall.prevBinaryPath(),
+				defaultExecutorInstall.versionPath,
+				defaultExecutorInstall.prevVersionPath(),
+			} {
+				if err := os.Remove(p); err != nil && !os.IsNotExist(err) {
+					return err
+				}
 			}
 
 			if !quiet {
@@ -403,26 +622,14 @@ To update executor configuration, run uninstall and then install.`,
 		Args: cobra.NoArgs,
 	}
 
-	var version string
-	cmd.Flags().StringVar(
-		&version,
-		"version",
-		"",
-		"Version of the Beaker executor. Defaults to the latest version if empty.")
+	source := addExecutorSourceFlags(cmd)
 
 	cmd.RunE = func(cmd *cobra.Command, args []string) error {
 		if err := stopExecutor(); err != nil {
 			return err
 		}
 
-		if version == "" {
-			var err error
-			version, err = getLatestVersion()
-			if err != nil {
-				return err
-			}
-		}
-		if err := downloadExecutor(version); err != nil {
+		if err := source.install(); err != nil {
 			return err
 		}
 
@@ -438,39 +645,384 @@ To update executor configuration, run uninstall and then install.`,
 	return cmd
 }
 
-func downloadExecutor(version string) error {
-	resp, err := http.Get(fmt.Sprintf(executorURL, version))
+// executorSourceFlags select where an executor binary is installed from.
+type executorSourceFlags struct {
+	version string
+	binary  string
+	mirror  string
+}
+
+func addExecutorSourceFlags(cmd *cobra.Command) *executorSourceFlags {
+	flags := &executorSourceFlags{}
+	cmd.Flags().StringVar(
+		&flags.version,
+		"version",
+		"",
+		"Version of the Beaker executor. Commit SHA from allenai/beaker-service. Defaults to the latest version if empty.")
+	cmd.Flags().StringVar(
+		&flags.binary,
+		"binary",
+		"",
+		"Install the executor from a local file instead of downloading it.")
+	cmd.Flags().StringVar(
+		&flags.mirror,
+		"mirror",
+		"",
+		fmt.Sprintf(
+			"Base URL to download the executor from. Defaults to executor_mirror in the Beaker config or %s.",
+			defaultExecutorMirror))
+	return flags
+}
+
+// install installs the executor binary from the selected source.
+func (f *executorSourceFlags) install() error {
+	if f.binary != "" {
+		if f.mirror != "" {
+			return fmt.Errorf("only one of --binary and --mirror may be set")
+		}
+		return copyExecutor(f.binary, f.version, defaultExecutorInstall)
+	}
+
+	mirror := f.mirror
+	if mirror == "" {
+		mirror = beakerConfig.ExecutorMirror
+	}
+	if mirror == "" {
+		mirror = defaultExecutorMirror
+	}
+	mirror = strings.TrimSuffix(mirror, "/")
+
+	version := f.version
+	if version == "" {
+		var err error
+		if version, err = getLatestVersion(mirror); err != nil {
+			return err
+		}
+	}
+	return downloadExecutor(mirror, version, defaultExecutorInstall)
+}
+
+// copyExecutor installs an executor binary from a local file. Local binaries
+// are recorded by digest unless a version is given.
+func copyExecutor(binary string, version string, dst executorInstall) error {
+	in, err := os.Open(binary)
 	if err != nil {
 		return err
 	}
-	defer resp.Body.Close()
+	defer in.Close()
 
-	out, err := os.Create(executorPath)
+	// Copy next to the executor so that it can be renamed into place.
+	out, err := ioutil.TempFile(path.Dir(dst.binaryPath), ".beaker-executor-")
 	if err != nil {
 		return err
 	}
+	defer os.Remove(out.Name())
 	defer out.Close()
 
-	_, err = io.Copy(out, resp.Body)
+	hash := sha256.New()
+	if _, err := io.Copy(io.MultiWriter(out, hash), in); err != nil {
+		return fmt.Errorf("copying executor: %w", err)
+	}
+	if err := out.Close(); err != nil {
+		return err
+	}
+
+	if version == "" {
+		version = "local-" + hex.EncodeToString(hash.Sum(nil))[:12]
+	}
+	return installExecutor(out.Name(), version, dst)
+}
+
+// downloadExecutor downloads and installs an executor binary after verifying
+// it against its published SHA-256 digest.
+func downloadExecutor(mirror string, version string, dst executorInstall) error {
+	url := fmt.Sprintf(executorURL, mirror, version)
+	digest, err := httpGetString(url + executorDigestSuffix)
 	if err != nil {
+		return fmt.Errorf("getting executor digest: %w", err)
+	}
+	// Digest files may be in sha256sum format: "<digest>  <filename>"
+	if fields := strings.Fields(digest); len(fields) > 0 {
+		digest = strings.ToLower(fields[0])
+	}
+
+	resp, err := http.Get(url)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("downloading executor %s: %s", version, resp.Status)
+	}
+
+	// Download next to the executor so that it can be renamed into place.
+	out, err := ioutil.TempFile(path.Dir(dst.binaryPath), ".beaker-executor-")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(out.Name())
+	defer out.Close()
+
+	hash := sha256.New()
+	if _, err := io.Copy(io.MultiWriter(out, hash), resp.Body); err != nil {
+		return fmt.Errorf("downloading executor %s: %w", version, err)
+	}
+	if err := out.Close(); err != nil {
+		return err
+	}
+	if actual := hex.EncodeToString(hash.Sum(nil)); actual != digest {
+		return fmt.Errorf("executor %s failed verification: expected SHA-256 %s, got %s", version, digest, actual)
+	}
+
+	return installExecutor(out.Name(), version, dst)
+}
+
+// installExecutor atomically replaces the executor binary with the given file,
+// which must be on the same filesystem. The replaced binary is kept for rollback.
+func installExecutor(binary string, version string, dst executorInstall) error {
+	if err := os.Chmod(binary, 0700); err != nil {
+		return err
+	}
+
+	if _, err := os.Stat(dst.binaryPath); err == nil {
+		if err := backupFile(dst.binaryPath, dst.prevBinaryPath()); err != nil {
+			return fmt.Errorf("keeping previous executor: %w", err)
+		}
+		if err := backupFile(dst.versionPath, dst.prevVersionPath()); err != nil {
+			return fmt.Errorf("keeping previous executor version: %w", err)
+		}
+	} else if !os.IsNotExist(err) {
+		return err
+	}
+
+	if err := os.Rename(binary, dst.binaryPath); err != nil {
+		return err
+	}
+
+	// Replace the version file rather than writing to it, since writing would
+	// also change the backup linked to it.
+	tmp := dst.versionPath + ".tmp"
+	if err := ioutil.WriteFile(tmp, []byte(version+"\n"), 0644); err != nil {
 		return err
 	}
+	return os.Rename(tmp, dst.versionPath)
+}
 
-	return os.Chmod(executorPath, 0700)
+// backupFile hard links a file to a backup path, replacing any previous
+// backup. A missing file removes the backup.
+func backupFile(file, backup string) error {
+	if err := os.Remove(backup); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	if err := os.Link(file, backup); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	return nil
 }
 
-func getLatestVersion() (string, error) {
-	resp, err := http.Get(versionURL)
+// swapFiles exchanges two files. Either file may be missing.
+func swapFiles(a, b string) error {
+	tmp := a + ".swap"
+	if err := os.Rename(a, tmp); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	if err := os.Rename(b, a); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	if err := os.Rename(tmp, b); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	return nil
+}
+
+func getLatestVersion(mirror string) (string, error) {
+	return httpGetString(fmt.Sprintf(versionURL, mirror))
+}
+
+// httpGetString gets the trimmed body of a URL.
+func httpGetString(url string) (string, error) {
+	resp, err := http.Get(url)
 	if err != nil {
 		return "", err
 	}
 	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("GET %s: %s", url, resp.Status)
+	}
+
+	body, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(string(body)), nil
+}
 
-	version, err := ioutil.ReadAll(resp.Body)
+// getExecutorStoragePath returns the executor's storage path, falling back to
+// the executor's default if the config doesn't set one.
+func getExecutorStoragePath(config *executorConfig) (string, error) {
+	if config.StoragePath != "" {
+		return config.StoragePath, nil
+	}
+	home, err := os.UserHomeDir()
 	if err != nil {
 		return "", err
 	}
-	return strings.TrimSpace(string(version)), nil
+	return path.Join(home, ".beaker", "storage"), nil
+}
+
+// getExecutorState returns the executor service's state as reported by
+// systemd, e.g. "active" or "failed".
+func getExecutorState() string {
+	// is-active exits non-zero for inactive services, but still prints the state.
+	out, _ := exec.CommandContext(ctx, "systemctl", "is-active", executorService).Output()
+	if state := strings.TrimSpace(string(out)); state != "" {
+		return state
+	}
+	return "unknown"
+}
+
+// getExecutorLastLog returns the time of the executor's most recent log entry.
+func getExecutorLastLog() *time.Time {
+	out, err := exec.CommandContext(
+		ctx,
+		"journalctl",
+		"--unit", executorService,
+		"--lines", "1",
+		"--output", "json",
+		"--no-pager").Output()
+	if err != nil {
+		return nil
+	}
+	var entry struct {
+		RealtimeTimestamp string `json:"__REALTIME_TIMESTAMP"`
+	}
+	if err := json.Unmarshal(out, &entry); err != nil {
+		return nil
+	}
+	usec, err := strconv.ParseInt(entry.RealtimeTimestamp, 10, 64)
+	if err != nil {
+		return nil
+	}
+	t := time.Unix(0, usec*int64(time.Microsecond))
+	return &t
+}
+
+// diskUsage returns the used and total size of the filesystem containing path.
+func diskUsage(path string) (used, total *bytefmt.Size, err error) {
+	var fs syscall.Statfs_t
+	if err := syscall.Statfs(path, &fs); err != nil {
+		return nil, nil, err
+	}
+	blockSize := int64(fs.Bsize)
+	total = bytefmt.New(int64(fs.Blocks)*blockSize, bytefmt.Binary)
+	used = bytefmt.New(int64(fs.Blocks-fs.Bfree)*blockSize, bytefmt.Binary)
+	return used, total, nil
+}
+
+// countRunningContainers counts running containers managed by Beaker.
+func countRunningContainers() (int, error) {
+	rt, err := docker.NewRuntime()
+	if err != nil {
+		return 0, err
+	}
+	defer rt.Close()
+	containers, err := rt.ListContainers(ctx)
+	if err != nil {
+		return 0, err
+	}
+	var running int
+	for _, c := range containers {
+		info, err := c.Info(ctx)
+		if err != nil {
+			return 0, err
+		}
+		if info.Status == runtime.StatusRunning {
+			running++
+		}
+	}
+	return running, nil
+}
+
+func checkExecutorActive() error {
+	if state := getExecutorState(); state != "active" {
+		return fmt.Errorf("service is %s; see \"journalctl -u %s\"", state, executorService)
+	}
+	return nil
+}
+
+func checkTokenFile(path string) error {
+	info, err := os.Stat(path)
+	if err != nil {
+		return err
+	}
+	if perm := info.Mode().Perm(); perm != 0600 {
+		return fmt.Errorf("permissions are %#o; expected 0600", perm)
+	}
+	return nil
+}
+
+func checkWritable(dir string) error {
+	f, err := ioutil.TempFile(dir, ".doctor-")
+	if err != nil {
+		return err
+	}
+	_ = f.Close()
+	return os.Remove(f.Name())
+}
+
+func checkDocker() error {
+	rt, err := docker.NewRuntime()
+	if err != nil {
+		return err
+	}
+	defer rt.Close()
+	_, err = rt.ListContainers(ctx)
+	return err
+}
+
+func checkDir(dir string) error {
+	info, err := os.Stat(dir)
+	if err != nil {
+		return err
+	}
+	if !info.IsDir() {
+		return fmt.Errorf("not a directory")
+	}
+	return nil
+}
+
+// checkNodeRegistration checks that the node recorded by the executor exists,
+// belongs to the configured cluster, and matches this machine.
+func checkNodeRegistration(config *executorConfig) error {
+	nodeID, err := getCurrentNode()
+	if err != nil {
+		return fmt.Errorf("executor has not registered a node: %w", err)
+	}
+
+	node, err := beaker.Node(nodeID).Get(ctx)
+	if err != nil {
+		return fmt.Errorf("getting node %s: %w", nodeID, err)
+	}
+	if node.Expiry != nil && node.Expiry.Before(time.Now()) {
+		return fmt.Errorf("node %s expired at %s", nodeID, node.Expiry.Local().Format(time.RFC3339))
+	}
+	if hostname, err := os.Hostname(); err == nil && node.Hostname != hostname {
+		return fmt.Errorf("node %s is registered to host %q, not %q", nodeID, node.Hostname, hostname)
+	}
+
+	if config.Beaker.Cluster != "" {
+		nodes, err := beaker.Cluster(config.Beaker.Cluster).ListClusterNodes(ctx)
+		if err != nil {
+			return fmt.Errorf("listing nodes in cluster %s: %w", config.Beaker.Cluster, err)
+		}
+		for _, n := range nodes {
+			if n.ID == nodeID {
+				return nil
+			}
+		}
+		return fmt.Errorf("node %s is not in cluster %s", nodeID, config.Beaker.Cluster)
+	}
+	return nil
 }
 
 func startExecutor() error {
@@ -0,0 +1,119 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"path"
+	"strings"
+	"testing"
+)
+
+// newExecutorMirror serves executor releases in the layout of the default
+// mirror. Each version maps to its binary; digests may be overridden.
+func newExecutorMirror(t *testing.T, binaries, digests map[string]string) *httptest.Server {
+	mux := http.NewServeMux()
+	for version, binary := range binaries {
+		binary := binary
+		digest, ok := digests[version]
+		if !ok {
+			sum := sha256.Sum256([]byte(binary))
+			digest = hex.EncodeToString(sum[:]) + "  executor"
+		}
+		mux.HandleFunc("/"+version+"/executor", func(w http.ResponseWriter, r *http.Request) {
+			w.Write([]byte(binary))
+		})
+		mux.HandleFunc("/"+version+"/executor"+executorDigestSuffix, func(w http.ResponseWriter, r *http.Request) {
+			w.Write([]byte(digest + "\n"))
+		})
+	}
+	server := httptest.NewServer(mux)
+	t.Cleanup(server.Close)
+	return server
+}
+
+// newTestExecutorInstall creates an existing install of version "old".
+func newTestExecutorInstall(t *testing.T) executorInstall {
+	dir := t.TempDir()
+	dst := executorInstall{
+		binaryPath:  path.Join(dir, "beaker-executor"),
+		versionPath: path.Join(dir, "executor-version"),
+	}
+	writeTestFile(t, dst.binaryPath, "old binary")
+	writeTestFile(t, dst.versionPath, "old\n")
+	return dst
+}
+
+func writeTestFile(t *testing.T, name, content string) {
+	if err := ioutil.WriteFile(name, []byte(content), 0600); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func assertFile(t *testing.T, name, expected string) {
+	t.Helper()
+	b, err := ioutil.ReadFile(name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(b) != expected {
+		t.Errorf("%s: expected %q, got %q", path.Base(name), expected, string(b))
+	}
+}
+
+func TestDownloadExecutor(t *testing.T) {
+	mirror := newExecutorMirror(t, map[string]string{"new": "new binary"}, nil)
+	dst := newTestExecutorInstall(t)
+
+	if err := downloadExecutor(mirror.URL, "new", dst); err != nil {
+		t.Fatal(err)
+	}
+	assertFile(t, dst.binaryPath, "new binary")
+	assertFile(t, dst.versionPath, "new\n")
+	assertFile(t, dst.prevBinaryPath(), "old binary")
+	assertFile(t, dst.prevVersionPath(), "old\n")
+}
+
+func TestDownloadExecutorDigestMismatch(t *testing.T) {
+	mirror := newExecutorMirror(t,
+		map[string]string{"new": "new binary"},
+		map[string]string{"new": strings.Repeat("0", 64)})
+	dst := newTestExecutorInstall(t)
+
+	err := downloadExecutor(mirror.URL, "new", dst)
+	if err == nil || !strings.Contains(err.Error(), "failed verification") {
+		t.Fatalf("expected a verification error, got %v", err)
+	}
+	assertFile(t, dst.binaryPath, "old binary")
+	assertFile(t, dst.versionPath, "old\n")
+	assertNoTempFiles(t, dst)
+}
+
+func TestDownloadExecutorNotFound(t *testing.T) {
+	mirror := newExecutorMirror(t, nil, nil)
+	dst := newTestExecutorInstall(t)
+
+	err := downloadExecutor(mirror.URL, "missing", dst)
+	if err == nil || !strings.Contains(err.Error(), "404") {
+		t.Fatalf("expected a 404 error, got %v", err)
+	}
+	assertFile(t, dst.binaryPath, "old binary")
+	assertFile(t, dst.versionPath, "old\n")
+	assertNoTempFiles(t, dst)
+}
+
+// assertNoTempFiles checks that a failed download left nothing behind.
+func assertNoTempFiles(t *testing.T, dst executorInstall) {
+	t.Helper()
+	files, err := ioutil.ReadDir(path.Dir(dst.binaryPath))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, f := range files {
+		if strings.HasPrefix(f.Name(), ".beaker-executor-") {
+			t.Errorf("temporary file %s was left behind", f.Name())
+		}
+	}
+}
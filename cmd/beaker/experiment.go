@@ -3,11 +3,13 @@ package main
 import (
 	"bytes"
 	"context"
+	"errors"
 	"fmt"
 	"io"
 	"io/ioutil"
 	"os"
 	"path"
+	"regexp"
 	"strconv"
 	"strings"
 	"text/template"
@@ -17,6 +19,7 @@ import (
 	"github.com/beaker/client/client"
 	"github.com/fatih/color"
 	"github.com/spf13/cobra"
+	"gopkg.in/yaml.v3"
 )
 
 func newExperimentCommand() *cobra.Command {
@@ -27,11 +30,17 @@ func newExperimentCommand() *cobra.Command {
 	cmd.AddCommand(newExperimentAwaitCommand())
 	cmd.AddCommand(newExperimentCreateCommand())
 	cmd.AddCommand(newExperimentDeleteCommand())
+	cmd.AddCommand(newExperimentDiffCommand())
 	cmd.AddCommand(newExperimentGroupsCommand())
 	cmd.AddCommand(newExperimentGetCommand())
+	cmd.AddCommand(newExperimentGraphCommand())
+	cmd.AddCommand(newExperimentMoveCommand())
 	cmd.AddCommand(newExperimentRenameCommand())
+	cmd.AddCommand(newExperimentRenamePrefixCommand())
+	cmd.AddCommand(newExperimentRerunCommand())
 	cmd.AddCommand(newExperimentResultsCommand())
 	cmd.AddCommand(newExperimentResumeCommand())
+	cmd.AddCommand(newExperimentRetryCommand())
 	cmd.AddCommand(newExperimentSpecCommand())
 	cmd.AddCommand(newExperimentStopCommand())
 	cmd.AddCommand(newExperimentTasksCommand())
@@ -159,19 +168,188 @@ func newExperimentCreateCommand() *cobra.Command {
 }
 
 func newExperimentDeleteCommand() *cobra.Command {
-	return &cobra.Command{
-		Use:   "delete <experiment>",
+	cmd := &cobra.Command{
+		Use:   "delete [experiment]",
 		Short: "Permanently delete an experiment",
-		Args:  cobra.ExactArgs(1),
-		RunE: func(cmd *cobra.Command, args []string) error {
+		Long: `Permanently delete an experiment.
+
+Delete all experiments in a workspace matching filters by omitting the
+experiment, e.g. "beaker experiment delete --author me --text sweep-3".`,
+		Args: cobra.MaximumNArgs(1),
+	}
+	filters := addExperimentFilterFlags(cmd)
+
+	cmd.RunE = func(cmd *cobra.Command, args []string) error {
+		if len(args) == 1 {
+			if filters.isSet(cmd) {
+				return errors.New("filters can't be combined with an experiment")
+			}
 			if err := beaker.Experiment(args[0]).Delete(ctx); err != nil {
 				return err
 			}
 
 			fmt.Printf("Deleted %s\n", color.BlueString(args[0]))
 			return nil
-		},
+		}
+
+		if !filters.narrows(cmd) {
+			return errors.New("an experiment or at least one of --author, --status or --text is required")
+		}
+		experiments, err := filters.list()
+		if err != nil {
+			return err
+		}
+		if ok, err := confirmExperiments(experiments, "permanently delete", filters.yes); err != nil || !ok {
+			return err
+		}
+		return bulkExperiments(experiments, "delete", "deleted", func(experiment api.Experiment) error {
+			return beaker.Experiment(experiment.ID).Delete(ctx)
+		})
+	}
+	return cmd
+}
+
+func newExperimentDiffCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "diff <experiment> <experiment>",
+		Short: "Compare the specs, images, datasets and metrics of two experiments",
+		Long: `Compare the specs, images, datasets and metrics of two experiments.
+
+Specs are compared after sorting their fields. Tasks are matched by name, and
+the images and datasets used by each task's latest job are listed where they
+differ. Metrics of each task's latest job are listed side by side, or N/A
+where a job has no results.`,
+		Args: cobra.ExactArgs(2),
 	}
+
+	var sideBySide bool
+	var contextLines int
+	cmd.Flags().BoolVarP(&sideBySide, "side-by-side", "y", false, "Show spec differences in two columns")
+	cmd.Flags().IntVarP(&contextLines, "context", "U", 3, "Lines of context around spec differences")
+
+	cmd.RunE = func(cmd *cobra.Command, args []string) error {
+		a, err := getExperimentSummary(args[0])
+		if err != nil {
+			return err
+		}
+		b, err := getExperimentSummary(args[1])
+		if err != nil {
+			return err
+		}
+		return printExperimentDiff(a, b, sideBySide, contextLines)
+	}
+	return cmd
+}
+
+// experimentSummary is the part of an experiment compared by "experiment diff".
+type experimentSummary struct {
+	Name  string
+	Spec  []string // Canonical YAML lines.
+	Tasks []taskSummary
+}
+
+// taskSummary describes the latest job of a task.
+type taskSummary struct {
+	Name     string
+	Image    string
+	Datasets map[string]string // Source by mount path.
+	Metrics  map[string]interface{}
+}
+
+func getExperimentSummary(ref string) (*experimentSummary, error) {
+	experiment, err := beaker.Experiment(ref).Get(ctx)
+	if err != nil {
+		return nil, err
+	}
+	summary := &experimentSummary{Name: experiment.ID}
+	if experiment.FullName != "" {
+		summary.Name = experiment.FullName
+	}
+
+	doc, err := getExperimentSpecNode(experiment.ID)
+	if err != nil {
+		return nil, err
+	}
+	// Decoding into an interface sorts mapping keys when encoded.
+	var spec interface{}
+	if err := doc.Decode(&spec); err != nil {
+		return nil, err
+	}
+	var canonical yaml.Node
+	if err := canonical.Encode(spec); err != nil {
+		return nil, err
+	}
+	b, err := encodeYAML(&canonical)
+	if err != nil {
+		return nil, err
+	}
+	summary.Spec = strings.Split(strings.TrimSuffix(string(b), "\n"), "\n")
+
+	tasks, err := beaker.Experiment(experiment.ID).Tasks(ctx)
+	if err != nil {
+		return nil, err
+	}
+	for i, task := range tasks {
+		t := taskSummary{Name: task.Name}
+		if t.Name == "" {
+			t.Name = fmt.Sprintf("#%d", i)
+		}
+		if len(task.Jobs) > 0 {
+			job := task.Jobs[len(task.Jobs)-1] // Use last job.
+			if job.Execution != nil {
+				t.Image = imageSourceString(job.Execution.Spec.Image)
+				t.Datasets = make(map[string]string)
+				for _, mount := range job.Execution.Spec.Datasets {
+					t.Datasets[mount.MountPath] = dataMountString(mount)
+				}
+			}
+			if job.Status.Finalized != nil {
+				// Jobs which failed may have no results. Their metrics are
+				// shown as N/A rather than stopping the comparison.
+				results, err := beaker.Job(job.ID).GetResults(ctx)
+				switch {
+				case isNotFound(err):
+				case err != nil:
+					return nil, fmt.Errorf("getting results of %s: %w", job.ID, err)
+				default:
+					t.Metrics = results.Metrics
+				}
+			}
+		}
+		summary.Tasks = append(summary.Tasks, t)
+	}
+	return summary, nil
+}
+
+func imageSourceString(image api.ImageSource) string {
+	switch {
+	case image.Beaker != "":
+		return "beaker://" + image.Beaker
+	case image.Docker != "":
+		return "docker://" + image.Docker
+	default:
+		return ""
+	}
+}
+
+func dataMountString(mount api.DataMount) string {
+	var source string
+	switch s := mount.Source; {
+	case s.Beaker != "":
+		source = "beaker: " + s.Beaker
+	case s.HostPath != "":
+		source = "hostPath: " + s.HostPath
+	case s.Result != "":
+		source = "result: " + s.Result
+	case s.URL != "":
+		source = "url: " + s.URL
+	case s.Secret != "":
+		source = "secret: " + s.Secret
+	}
+	if mount.SubPath != "" {
+		source += " (" + mount.SubPath + ")"
+	}
+	return source
 }
 
 func newExperimentGroupsCommand() *cobra.Command {
@@ -219,6 +397,123 @@ func newExperimentGetCommand() *cobra.Command {
 	}
 }
 
+func newExperimentGraphCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "graph <experiment|spec-file>",
+		Short: "Draw the dependencies between an experiment's tasks",
+		Long: `Draw the dependencies between an experiment's tasks.
+
+A task depends on another when it mounts that task's result. Tasks of an
+existing experiment are annotated with the status of their latest job. The
+graph may be printed as a tree, in the Graphviz DOT language or as a Mermaid
+flowchart, e.g.
+
+  beaker experiment graph --style dot <experiment> | dot -Tsvg > graph.svg`,
+		Args: cobra.ExactArgs(1),
+	}
+
+	var style string
+	cmd.Flags().StringVar(&style, "style", "tree", "Style of graph: tree, dot or mermaid")
+
+	cmd.RunE = func(cmd *cobra.Command, args []string) error {
+		var render func(io.Writer, []taskNode)
+		switch style {
+		case "tree":
+			render = printTaskTree
+		case "dot":
+			render = printTaskDOT
+		case "mermaid":
+			render = printTaskMermaid
+		default:
+			return fmt.Errorf("invalid style %q; must be one of tree, dot, mermaid", style)
+		}
+
+		var spec api.ExperimentSpecV2
+		var tasks []*api.Task
+		if _, err := os.Stat(args[0]); err == nil || args[0] == "-" {
+			specFile, err := openPath(args[0])
+			if err != nil {
+				return err
+			}
+			rawSpec, err := readSpec(specFile)
+			if err != nil {
+				return err
+			}
+			if err := yaml.Unmarshal(rawSpec, &spec); err != nil {
+				return fmt.Errorf("parsing spec: %w", err)
+			}
+			switch spec.Version {
+			case "v2", "v2-alpha":
+			case "":
+				return errors.New("the spec has no version; only v2 specs can be graphed")
+			default:
+				return fmt.Errorf("unsupported spec version %q; only v2 specs can be graphed", spec.Version)
+			}
+		} else {
+			experiment, err := beaker.Experiment(args[0]).Get(ctx)
+			if err != nil {
+				return err
+			}
+			doc, err := getExperimentSpecNode(experiment.ID)
+			if err != nil {
+				return err
+			}
+			if err := doc.Decode(&spec); err != nil {
+				return fmt.Errorf("parsing spec: %w", err)
+			}
+			created, err := beaker.Experiment(experiment.ID).Tasks(ctx)
+			if err != nil {
+				return err
+			}
+			tasks = matchSpecTasks(spec.Tasks, created)
+		}
+
+		nodes := buildTaskGraph(spec.Tasks, tasks)
+		if format == formatJSON {
+			return printJSON(nodes)
+		}
+		render(os.Stdout, nodes)
+		return nil
+	}
+	return cmd
+}
+
+func newExperimentMoveCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "move <workspace>",
+		Short: "Move experiments matching filters into a workspace",
+		Long: `Move experiments matching filters into a workspace.
+
+Experiments are found in the workspace given by --workspace, e.g.
+"beaker experiment move archive --workspace scratch --text sweep-3".
+To move specific experiments, use "beaker workspace move".`,
+		Args: cobra.ExactArgs(1),
+	}
+	filters := addExperimentFilterFlags(cmd)
+
+	cmd.RunE = func(cmd *cobra.Command, args []string) error {
+		if !filters.narrows(cmd) {
+			return errors.New("at least one of --author, --status or --text is required")
+		}
+		target := beaker.Workspace(args[0])
+		if _, err := target.Get(ctx); err != nil {
+			return err
+		}
+
+		experiments, err := filters.list()
+		if err != nil {
+			return err
+		}
+		if ok, err := confirmExperiments(experiments, "move", filters.yes); err != nil || !ok {
+			return err
+		}
+		return bulkExperiments(experiments, "move", "moved", func(experiment api.Experiment) error {
+			return target.Transfer(ctx, experiment.ID)
+		})
+	}
+	return cmd
+}
+
 func newExperimentRenameCommand() *cobra.Command {
 	return &cobra.Command{
 		Use:   "rename <experiment> <name>",
@@ -244,6 +539,203 @@ func newExperimentRenameCommand() *cobra.Command {
 	}
 }
 
+func newExperimentRenamePrefixCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "rename-prefix <old-prefix> <new-prefix>",
+		Short: "Rename experiments by replacing the start of their names",
+		Long: `Rename experiments by replacing the start of their names.
+
+Experiments in the workspace whose names start with the old prefix and match
+any other filters are renamed.`,
+		Args: cobra.ExactArgs(2),
+	}
+	filters := addExperimentFilterFlags(cmd)
+
+	cmd.RunE = func(cmd *cobra.Command, args []string) error {
+		oldPrefix, newPrefix := args[0], args[1]
+		if oldPrefix == "" {
+			return errors.New("the old prefix must not be empty")
+		}
+		if filters.text == "" {
+			filters.text = oldPrefix
+		}
+
+		matches, err := filters.list()
+		if err != nil {
+			return err
+		}
+		var experiments []api.Experiment
+		for _, experiment := range matches {
+			if strings.HasPrefix(experiment.Name, oldPrefix) {
+				experiments = append(experiments, experiment)
+			}
+		}
+
+		if ok, err := confirmExperiments(experiments, "rename", filters.yes); err != nil || !ok {
+			return err
+		}
+		return bulkExperiments(experiments, "rename", "renamed", func(experiment api.Experiment) error {
+			name := newPrefix + strings.TrimPrefix(experiment.Name, oldPrefix)
+			_, err := beaker.Experiment(experiment.ID).Patch(ctx, api.ExperimentPatch{Name: &name})
+			return err
+		})
+	}
+	return cmd
+}
+
+func newExperimentRerunCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "rerun <experiment>",
+		Short: "Create a new experiment from an existing experiment's spec",
+		Long: `Create a new experiment from an existing experiment's spec.
+
+Fields of the spec may be overridden with --set using paths such as
+"tasks[0].resources.gpuCount=2". Values are parsed as YAML. An index of "*"
+sets a field in every element of a list, e.g. "tasks[*].context.priority=high".`,
+		Args: cobra.ExactArgs(1),
+	}
+
+	var overrides []string
+	var image string
+	var name string
+	var workspace string
+	var dryRun bool
+	cmd.Flags().StringArrayVar(&overrides, "set", nil, "Override a field of the spec as path=value")
+	cmd.Flags().StringVarP(&image, "image", "i", "", "Image to run in every task, e.g. beaker://org/image or docker://image")
+	cmd.Flags().StringVarP(&name, "name", "n", "", "Assign a name to the experiment")
+	cmd.Flags().StringVarP(&workspace, "workspace", "w", "", "Workspace where the experiment will be placed, defaults to the original's")
+	cmd.Flags().BoolVar(&dryRun, "dry-run", false, "Print the spec instead of creating an experiment")
+
+	cmd.RunE = func(cmd *cobra.Command, args []string) error {
+		parent, err := beaker.Experiment(args[0]).Get(ctx)
+		if err != nil {
+			return err
+		}
+		if workspace == "" {
+			workspace = parent.Workspace.FullName
+		}
+
+		doc, err := getExperimentSpecNode(parent.ID)
+		if err != nil {
+			return err
+		}
+		root := doc.Content[0]
+
+		if image != "" {
+			source, err := getImageSource(image)
+			if err != nil {
+				return err
+			}
+			value := &yaml.Node{}
+			if err := value.Encode(source); err != nil {
+				return err
+			}
+			if err := setYAMLPath(root, []yamlPathElem{{key: "tasks", isKey: true}, {index: -1}, {key: "image", isKey: true}}, value); err != nil {
+				return err
+			}
+		}
+
+		for _, override := range overrides {
+			parts := strings.SplitN(override, "=", 2)
+			if len(parts) != 2 {
+				return fmt.Errorf("invalid override %q; must be in the form path=value", override)
+			}
+			path, err := parseYAMLPath(parts[0])
+			if err != nil {
+				return err
+			}
+			var value yaml.Node
+			if err := yaml.Unmarshal([]byte(parts[1]), &value); err != nil {
+				return fmt.Errorf("invalid value for %s: %w", parts[0], err)
+			}
+			if len(value.Content) == 0 {
+				// An empty string parses as an empty document.
+				value.Content = []*yaml.Node{{Kind: yaml.ScalarNode, Tag: "!!str"}}
+			}
+			if err := setYAMLPath(root, path, value.Content[0]); err != nil {
+				return fmt.Errorf("--set %s: %w", parts[0], err)
+			}
+		}
+
+		var description string
+		if node := findYAMLNode(root, "description"); node != nil {
+			description = node.Value
+		}
+		if err := setYAMLPath(root, []yamlPathElem{{key: "description", isKey: true}}, &yaml.Node{
+			Kind:  yaml.ScalarNode,
+			Tag:   "!!str",
+			Value: rerunDescription(description, parent.ID),
+		}); err != nil {
+			return err
+		}
+
+		spec, err := encodeYAML(doc)
+		if err != nil {
+			return err
+		}
+		if dryRun {
+			fmt.Print(string(spec))
+			return nil
+		}
+
+		experiment, err := beaker.Workspace(workspace).CreateExperimentRaw(
+			ctx,
+			"application/x-yaml",
+			bytes.NewReader(spec),
+			&client.ExperimentOpts{Name: name})
+		if err != nil {
+			return err
+		}
+
+		if format == formatJSON {
+			return printJSON([]api.Experiment{*experiment})
+		}
+
+		if quiet {
+			fmt.Println(experiment.ID)
+		} else {
+			fmt.Printf("Experiment %s submitted. See progress at %s/ex/%s\n",
+				color.BlueString(experiment.ID), beaker.Address(), experiment.ID)
+		}
+		return nil
+	}
+	return cmd
+}
+
+// getExperimentSpecNode fetches an experiment's spec as a YAML document.
+func getExperimentSpecNode(experiment string) (*yaml.Node, error) {
+	spec, err := beaker.Experiment(experiment).Spec(ctx, "v2-alpha", false)
+	if err != nil {
+		return nil, err
+	}
+	defer spec.Close()
+
+	b, err := ioutil.ReadAll(spec)
+	if err != nil {
+		return nil, err
+	}
+	var doc yaml.Node
+	if err := yaml.Unmarshal(b, &doc); err != nil {
+		return nil, fmt.Errorf("parsing spec: %w", err)
+	}
+	if len(doc.Content) == 0 || doc.Content[0].Kind != yaml.MappingNode {
+		return nil, errors.New("the experiment's spec is not a YAML mapping")
+	}
+	return &doc, nil
+}
+
+var rerunSuffix = regexp.MustCompile(`^Rerun of \S+$|\s*\(rerun of \S+\)$`)
+
+// rerunDescription records the experiment a rerun came from, replacing any
+// earlier record so descriptions don't grow with each rerun.
+func rerunDescription(description string, parent string) string {
+	description = rerunSuffix.ReplaceAllString(description, "")
+	if description == "" {
+		return "Rerun of " + parent
+	}
+	return fmt.Sprintf("%s (rerun of %s)", description, parent)
+}
+
 func newExperimentResultsCommand() *cobra.Command {
 	cmd := &cobra.Command{
 		Use:   "results <experiment>",
@@ -310,6 +802,318 @@ func newExperimentResumeCommand() *cobra.Command {
 	return cmd
 }
 
+func newExperimentRetryCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "retry <experiment>",
+		Short: "Retry the failed tasks of an experiment",
+		Long: `Retry the failed tasks of an experiment.
+
+A new experiment is created from the original's spec with only the tasks whose
+latest job failed or was canceled. Tasks which use the results of a retried
+task are retried with it. Results of tasks which succeeded are mounted from the
+original experiment rather than being computed again. Tasks run the same image
+as their last job, even if the spec's image has since been updated.
+
+Use "beaker experiment resume" instead for experiments which were preempted.`,
+		Args: cobra.ExactArgs(1),
+	}
+
+	var taskNames []string
+	var name string
+	var dryRun bool
+	cmd.Flags().StringArrayVar(&taskNames, "task", nil, "Only retry the named failed task; may be repeated")
+	cmd.Flags().StringVarP(&name, "name", "n", "", "Assign a name to the experiment")
+	cmd.Flags().BoolVar(&dryRun, "dry-run", false, "Print the spec instead of creating an experiment")
+
+	cmd.RunE = func(cmd *cobra.Command, args []string) error {
+		parent, err := beaker.Experiment(args[0]).Get(ctx)
+		if err != nil {
+			return err
+		}
+		tasks, err := beaker.Experiment(parent.ID).Tasks(ctx)
+		if err != nil {
+			return err
+		}
+		doc, err := getExperimentSpecNode(parent.ID)
+		if err != nil {
+			return err
+		}
+		var spec api.ExperimentSpecV2
+		if err := doc.Decode(&spec); err != nil {
+			return fmt.Errorf("parsing spec: %w", err)
+		}
+		if countUnnamedTasks(spec.Tasks) > 1 {
+			return errors.New("can't retry an experiment with more than one unnamed task; " +
+				`use "beaker experiment rerun" instead`)
+		}
+		specTasks := matchSpecTasks(spec.Tasks, tasks)
+
+		// Tasks are tracked by position since names are optional.
+		failed := make([]bool, len(spec.Tasks))
+		var anyFailed bool
+		for i, task := range specTasks {
+			failed[i] = task != nil && taskFailed(*task)
+			anyFailed = anyFailed || failed[i]
+		}
+		if len(taskNames) != 0 {
+			selected := make([]bool, len(spec.Tasks))
+			for _, taskName := range taskNames {
+				i := specTaskIndex(spec.Tasks, taskName)
+				if i < 0 {
+					return fmt.Errorf("task %q not found", taskName)
+				}
+				if !failed[i] {
+					return fmt.Errorf("task %q did not fail", taskName)
+				}
+				selected[i] = true
+			}
+			failed = selected
+		}
+		if !anyFailed {
+			fmt.Println("No failed tasks to retry.")
+			return nil
+		}
+		retry := downstreamTasks(spec.Tasks, failed)
+
+		// Keep the retried tasks and mount results of the others in their place.
+		root := doc.Content[0]
+		taskList := findYAMLNode(root, "tasks")
+		var kept []*yaml.Node
+		var reused []string
+		reusedSeen := make(map[int]bool)
+		for i, taskSpec := range spec.Tasks {
+			if !retry[i] {
+				continue
+			}
+			node := taskList.Content[i]
+
+			// The spec may name an image or tag which has since moved, so run
+			// the image the task last ran with.
+			if task := specTasks[i]; task != nil && len(task.Jobs) != 0 {
+				job := task.Jobs[len(task.Jobs)-1] // Use last job.
+				if job.Execution != nil {
+					image := &yaml.Node{}
+					if err := image.Encode(job.Execution.Spec.Image); err != nil {
+						return err
+					}
+					if err := setYAMLPath(node, []yamlPathElem{{key: "image", isKey: true}}, image); err != nil {
+						return err
+					}
+				}
+			}
+
+			for j, mount := range taskSpec.Datasets {
+				upstream := specTaskIndex(spec.Tasks, mount.Source.Result)
+				if upstream < 0 || retry[upstream] {
+					continue
+				}
+				result, err := taskResult(spec.Tasks[upstream].Name, specTasks[upstream])
+				if err != nil {
+					return fmt.Errorf("can't retry %q: %w", specTaskName(taskSpec, specTasks[i]), err)
+				}
+				source := &yaml.Node{}
+				if err := source.Encode(api.DataSource{Beaker: result}); err != nil {
+					return err
+				}
+				if err := setYAMLPath(node, []yamlPathElem{
+					{key: "datasets", isKey: true},
+					{index: j},
+					{key: "source", isKey: true},
+				}, source); err != nil {
+					return err
+				}
+				if !reusedSeen[upstream] {
+					reusedSeen[upstream] = true
+					reused = append(reused, fmt.Sprintf("%s (%s)", mount.Source.Result, result))
+				}
+			}
+			kept = append(kept, node)
+		}
+		taskList.Content = kept
+
+		var description string
+		if node := findYAMLNode(root, "description"); node != nil {
+			description = node.Value
+		}
+		if err := setYAMLPath(root, []yamlPathElem{{key: "description", isKey: true}}, &yaml.Node{
+			Kind:  yaml.ScalarNode,
+			Tag:   "!!str",
+			Value: rerunDescription(description, parent.ID),
+		}); err != nil {
+			return err
+		}
+
+		specYAML, err := encodeYAML(doc)
+		if err != nil {
+			return err
+		}
+		if dryRun {
+			fmt.Print(string(specYAML))
+			return nil
+		}
+
+		if !quiet && format != formatJSON {
+			var names []string
+			for i, task := range specTasks {
+				if retry[i] {
+					names = append(names, specTaskName(spec.Tasks[i], task))
+				}
+			}
+			fmt.Printf("Retrying %s\n", strings.Join(names, ", "))
+			if len(reused) != 0 {
+				fmt.Printf("Reusing results of %s\n", strings.Join(reused, ", "))
+			}
+		}
+
+		experiment, err := beaker.Workspace(parent.Workspace.FullName).CreateExperimentRaw(
+			ctx,
+			"application/x-yaml",
+			bytes.NewReader(specYAML),
+			&client.ExperimentOpts{Name: name})
+		if err != nil {
+			return err
+		}
+
+		if format == formatJSON {
+			return printJSON([]api.Experiment{*experiment})
+		}
+
+		if quiet {
+			fmt.Println(experiment.ID)
+		} else {
+			fmt.Printf("Experiment %s submitted. See progress at %s/ex/%s\n",
+				color.BlueString(experiment.ID), beaker.Address(), experiment.ID)
+		}
+		return nil
+	}
+	return cmd
+}
+
+// matchSpecTasks finds the task created for each task in a spec. Tasks are
+// matched by name. The order of tasks isn't guaranteed, so an unnamed task is
+// only matched if it's the only one. Tasks which weren't created or can't be
+// matched are nil.
+func matchSpecTasks(specs []api.TaskSpecV2, tasks []api.Task) []*api.Task {
+	byName := make(map[string]*api.Task)
+	var unnamed []*api.Task
+	for i := range tasks {
+		if tasks[i].Name != "" {
+			byName[tasks[i].Name] = &tasks[i]
+		} else {
+			unnamed = append(unnamed, &tasks[i])
+		}
+	}
+
+	onlyUnnamed := countUnnamedTasks(specs) == 1 && len(unnamed) == 1
+	matched := make([]*api.Task, len(specs))
+	for i, spec := range specs {
+		if spec.Name != "" {
+			matched[i] = byName[spec.Name]
+		} else if onlyUnnamed {
+			matched[i] = unnamed[0]
+		}
+	}
+	return matched
+}
+
+func countUnnamedTasks(specs []api.TaskSpecV2) int {
+	var n int
+	for _, spec := range specs {
+		if spec.Name == "" {
+			n++
+		}
+	}
+	return n
+}
+
+// taskFailed returns whether a task's latest job failed or was canceled.
+func taskFailed(task api.Task) bool {
+	if len(task.Jobs) == 0 {
+		return false
+	}
+	job := task.Jobs[len(task.Jobs)-1] // Use last job.
+	return job.Status.Canceled != nil || jobStatus(job.Status) == "failed"
+}
+
+// taskResult returns the result dataset of a task which succeeded.
+func taskResult(name string, task *api.Task) (string, error) {
+	if task == nil || len(task.Jobs) == 0 {
+		return "", fmt.Errorf("task %q has not run", name)
+	}
+	job := task.Jobs[len(task.Jobs)-1] // Use last job.
+	if jobStatus(job.Status) != "succeeded" || job.Execution == nil || job.Execution.Result.Beaker == "" {
+		return "", fmt.Errorf("task %q has no result to reuse; its latest job is %s",
+			name, jobStatus(job.Status))
+	}
+	return job.Execution.Result.Beaker, nil
+}
+
+// specTaskIndex returns the position of a named task in a spec, or -1.
+func specTaskIndex(specs []api.TaskSpecV2, name string) int {
+	if name == "" {
+		return -1
+	}
+	for i, spec := range specs {
+		if spec.Name == name {
+			return i
+		}
+	}
+	return -1
+}
+
+// specTaskName names a task by its name in the spec or else its ID.
+func specTaskName(spec api.TaskSpecV2, task *api.Task) string {
+	switch {
+	case spec.Name != "":
+		return spec.Name
+	case task != nil:
+		return task.ID
+	default:
+		return "(unnamed)"
+	}
+}
+
+// taskDependencies returns, for each task in a spec, the positions of the
+// tasks whose results it uses in the order they're mounted.
+func taskDependencies(specs []api.TaskSpecV2) [][]int {
+	deps := make([][]int, len(specs))
+	for i, spec := range specs {
+		seen := make(map[int]bool)
+		for _, mount := range spec.Datasets {
+			upstream := specTaskIndex(specs, mount.Source.Result)
+			if upstream >= 0 && !seen[upstream] {
+				seen[upstream] = true
+				deps[i] = append(deps[i], upstream)
+			}
+		}
+	}
+	return deps
+}
+
+// downstreamTasks returns the given tasks and every task which uses their
+// results, directly or through other tasks.
+func downstreamTasks(specs []api.TaskSpecV2, roots []bool) []bool {
+	deps := taskDependencies(specs)
+	result := make([]bool, len(specs))
+	copy(result, roots)
+	for changed := true; changed; {
+		changed = false
+		for i := range specs {
+			if result[i] {
+				continue
+			}
+			for _, upstream := range deps[i] {
+				if result[upstream] {
+					result[i] = true
+					changed = true
+					break
+				}
+			}
+		}
+	}
+	return result
+}
+
 func newExperimentSpecCommand() *cobra.Command {
 	cmd := &cobra.Command{
 		Use:   "spec <experiment>",
@@ -334,11 +1138,21 @@ func newExperimentSpecCommand() *cobra.Command {
 }
 
 func newExperimentStopCommand() *cobra.Command {
-	return &cobra.Command{
-		Use:   "stop <experiment...>",
+	cmd := &cobra.Command{
+		Use:   "stop [experiment...]",
 		Short: "Stop one or more running experiments",
-		Args:  cobra.MinimumNArgs(1),
-		RunE: func(cmd *cobra.Command, args []string) error {
+		Long: `Stop one or more running experiments.
+
+Stop all experiments in a workspace matching filters by omitting the
+experiments, e.g. "beaker experiment stop --author me --status running".`,
+	}
+	filters := addExperimentFilterFlags(cmd)
+
+	cmd.RunE = func(cmd *cobra.Command, args []string) error {
+		if len(args) > 0 {
+			if filters.isSet(cmd) {
+				return errors.New("filters can't be combined with experiments")
+			}
 			for _, name := range args {
 				if err := beaker.Experiment(name).Stop(ctx); err != nil {
 					// We want to stop as many of the requested experiments as possible.
@@ -349,8 +1163,23 @@ func newExperimentStopCommand() *cobra.Command {
 				fmt.Println(name)
 			}
 			return nil
-		},
+		}
+
+		if !filters.narrows(cmd) {
+			return errors.New("at least one experiment or one of --author, --status or --text is required")
+		}
+		experiments, err := filters.list()
+		if err != nil {
+			return err
+		}
+		if ok, err := confirmExperiments(experiments, "stop", filters.yes); err != nil || !ok {
+			return err
+		}
+		return bulkExperiments(experiments, "stop", "stopped", func(experiment api.Experiment) error {
+			return beaker.Experiment(experiment.ID).Stop(ctx)
+		})
 	}
+	return cmd
 }
 
 func newExperimentTasksCommand() *cobra.Command {
@@ -0,0 +1,136 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os/exec"
+	"strings"
+
+	"github.com/beaker/client/api"
+)
+
+// gpuDevice is a GPU attached to the local machine.
+type gpuDevice struct {
+	Index string `json:"index"`
+	UUID  string `json:"uuid"`
+}
+
+// listGPUDevices lists GPUs attached to the local machine. Returns nil if
+// nvidia-smi is not installed or fails.
+func listGPUDevices() []gpuDevice {
+	out, err := exec.Command(
+		"nvidia-smi",
+		"--query-gpu=index,uuid",
+		"--format=csv,noheader").Output()
+	if err != nil {
+		return nil
+	}
+
+	var devices []gpuDevice
+	for _, line := range strings.Split(strings.TrimSpace(string(out)), "\n") {
+		fields := strings.Split(line, ",")
+		if len(fields) != 2 {
+			continue
+		}
+		devices = append(devices, gpuDevice{
+			Index: strings.TrimSpace(fields[0]),
+			UUID:  strings.TrimSpace(fields[1]),
+		})
+	}
+	return devices
+}
+
+// findGPUDevice finds a device by index or UUID.
+func findGPUDevice(devices []gpuDevice, id string) (gpuDevice, bool) {
+	for _, d := range devices {
+		if d.Index == id || d.UUID == id {
+			return d, true
+		}
+	}
+	return gpuDevice{}, false
+}
+
+// gpuIndices converts GPU IDs to device indices where the device is known.
+func gpuIndices(devices []gpuDevice, ids []string) []string {
+	indices := make([]string, len(ids))
+	for i, id := range ids {
+		if d, ok := findGPUDevice(devices, id); ok {
+			indices[i] = d.Index
+		} else {
+			indices[i] = id
+		}
+	}
+	return indices
+}
+
+// gpuString describes a GPU by index and UUID where the device is known.
+func gpuString(devices []gpuDevice, id string) string {
+	if d, ok := findGPUDevice(devices, id); ok {
+		return fmt.Sprintf("%s (%s)", d.Index, d.UUID)
+	}
+	return id
+}
+
+// gpuRequest constrains which of a node's GPUs may be assigned to a session.
+type gpuRequest struct {
+	// Type is matched against the node's GPU type, ignoring case.
+	Type string
+
+	// IDs are the indices or UUIDs of GPUs the session must be assigned. They
+	// can't be reserved, only checked once the session starts.
+	IDs []string
+
+	// InUse are the GPUs assigned to other jobs on the node.
+	InUse []string
+
+	// Devices attached to the node, used to match indices with UUIDs. Devices
+	// are only known for the local node.
+	Devices []gpuDevice
+}
+
+// checkNodeGPUs checks whether a node can satisfy a GPU request.
+func checkNodeGPUs(node *api.Node, req *gpuRequest) error {
+	if req == nil {
+		return nil
+	}
+
+	if req.Type != "" && node.Limits != nil && !strings.Contains(
+		strings.ToLower(node.Limits.GPUType),
+		strings.ToLower(req.Type),
+	) {
+		if node.Limits.GPUType == "" {
+			return fmt.Errorf("the node has no %s GPUs", req.Type)
+		}
+		return fmt.Errorf("the node has %s GPUs", node.Limits.GPUType)
+	}
+
+	inUse := make(map[string]bool)
+	for _, id := range gpuIndices(req.Devices, req.InUse) {
+		inUse[id] = true
+	}
+	for _, id := range gpuIndices(req.Devices, req.IDs) {
+		if inUse[id] {
+			return fmt.Errorf("GPU %s is in use", id)
+		}
+	}
+	return nil
+}
+
+// validateGPUIDs checks that each ID refers to a distinct device.
+func validateGPUIDs(devices []gpuDevice, ids []string) error {
+	if devices == nil {
+		return errors.New("couldn't list GPUs on this machine; is nvidia-smi installed?")
+	}
+	seen := make(map[string]bool)
+	for _, id := range ids {
+		d, ok := findGPUDevice(devices, id)
+		if !ok {
+			return fmt.Errorf("GPU %s does not exist on this machine", id)
+		}
+		if seen[d.UUID] {
+			return fmt.Errorf("GPU %s is requested more than once", id)
+		}
+		seen[d.UUID] = true
+	}
+	return nil
+}
@@ -0,0 +1,200 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/beaker/client/api"
+	"github.com/fatih/color"
+)
+
+// taskNode is a task in an experiment's dependency graph.
+type taskNode struct {
+	Name      string   `json:"name"`
+	Status    string   `json:"status,omitempty"`
+	DependsOn []string `json:"dependsOn,omitempty"`
+
+	deps       []int // Positions of tasks whose results this task uses.
+	dependents []int // Positions of tasks which use this task's results.
+}
+
+// buildTaskGraph builds the dependency graph of tasks in a spec. Tasks are
+// annotated with the status of their latest job if they've been created.
+func buildTaskGraph(specs []api.TaskSpecV2, tasks []*api.Task) []taskNode {
+	nodes := make([]taskNode, len(specs))
+	for i, spec := range specs {
+		var task *api.Task
+		if tasks != nil {
+			task = tasks[i]
+			nodes[i].Status = "pending"
+			if task == nil && spec.Name == "" {
+				// Unnamed tasks can't always be matched with the task created.
+				nodes[i].Status = "unknown"
+			} else if task != nil && len(task.Jobs) != 0 {
+				nodes[i].Status = jobStatus(task.Jobs[len(task.Jobs)-1].Status) // Use last job.
+			}
+		}
+		nodes[i].Name = specTaskName(spec, task)
+	}
+	for i, deps := range taskDependencies(specs) {
+		nodes[i].deps = deps
+		for _, upstream := range deps {
+			nodes[i].DependsOn = append(nodes[i].DependsOn, nodes[upstream].Name)
+			nodes[upstream].dependents = append(nodes[upstream].dependents, i)
+		}
+	}
+	return nodes
+}
+
+func colorStatus(status string) string {
+	switch status {
+	case "succeeded":
+		return color.GreenString(status)
+	case "failed":
+		return color.RedString(status)
+	case "running", "uploading":
+		return color.CyanString(status)
+	default:
+		return color.YellowString(status)
+	}
+}
+
+// printTaskTree prints the graph as a tree from tasks which don't depend on
+// any others. Tasks which use results of several tasks appear under each of
+// them but their dependents are only expanded the first time.
+func printTaskTree(w io.Writer, nodes []taskNode) {
+	label := func(i int) string {
+		if nodes[i].Status == "" {
+			return nodes[i].Name
+		}
+		return fmt.Sprintf("%s (%s)", nodes[i].Name, colorStatus(nodes[i].Status))
+	}
+
+	expanded := make([]bool, len(nodes))
+	var visit func(i int, prefix, branch, indent string)
+	visit = func(i int, prefix, branch, indent string) {
+		if expanded[i] {
+			note := ""
+			if len(nodes[i].dependents) != 0 {
+				note = " ..."
+			}
+			fmt.Fprintln(w, prefix+branch+label(i)+note)
+			return
+		}
+		expanded[i] = true
+		fmt.Fprintln(w, prefix+branch+label(i))
+		for k, child := range nodes[i].dependents {
+			if k == len(nodes[i].dependents)-1 {
+				visit(child, prefix+indent, "└── ", "    ")
+			} else {
+				visit(child, prefix+indent, "├── ", "│   ")
+			}
+		}
+	}
+
+	// Tasks in a cycle have no root, so start from them once everything
+	// reachable has been printed.
+	for i := range nodes {
+		if len(nodes[i].deps) == 0 {
+			visit(i, "", "", "")
+		}
+	}
+	for i := range nodes {
+		if !expanded[i] {
+			visit(i, "", "", "")
+		}
+	}
+}
+
+// printTaskDOT prints the graph in the Graphviz DOT language.
+func printTaskDOT(w io.Writer, nodes []taskNode) {
+	fillColors := map[string]string{
+		"succeeded": "palegreen",
+		"failed":    "lightpink",
+		"running":   "lightblue",
+		"uploading": "lightblue",
+		"starting":  "lightyellow",
+		"pending":   "lightyellow",
+		"unknown":   "lightgray",
+	}
+
+	fmt.Fprintln(w, "digraph experiment {")
+	fmt.Fprintln(w, "  rankdir=LR;")
+	fmt.Fprintln(w, "  node [shape=box, style=\"rounded,filled\", fillcolor=white];")
+	for i, node := range nodes {
+		label := dotQuote(node.Name)
+		if node.Status != "" {
+			label = dotQuote(node.Name + "\n" + node.Status)
+		}
+		attrs := "label=" + label
+		if c, ok := fillColors[node.Status]; ok {
+			attrs += ", fillcolor=" + c
+		}
+		fmt.Fprintf(w, "  t%d [%s];\n", i, attrs)
+	}
+	for i, node := range nodes {
+		for _, upstream := range node.deps {
+			fmt.Fprintf(w, "  t%d -> t%d;\n", upstream, i)
+		}
+	}
+	fmt.Fprintln(w, "}")
+}
+
+func dotQuote(s string) string {
+	s = strings.ReplaceAll(s, `\`, `\\`)
+	s = strings.ReplaceAll(s, `"`, `\"`)
+	s = strings.ReplaceAll(s, "\n", `\n`)
+	return `"` + s + `"`
+}
+
+// printTaskMermaid prints the graph as a Mermaid flowchart.
+func printTaskMermaid(w io.Writer, nodes []taskNode) {
+	fmt.Fprintln(w, "flowchart LR")
+	for i, node := range nodes {
+		label := mermaidEscape(node.Name)
+		if node.Status != "" {
+			label += "<br/>" + node.Status
+		}
+		fmt.Fprintf(w, "  t%d[\"%s\"]\n", i, label)
+	}
+	for i, node := range nodes {
+		for _, upstream := range node.deps {
+			fmt.Fprintf(w, "  t%d --> t%d\n", upstream, i)
+		}
+	}
+
+	classes := map[string][]string{}
+	var order []string
+	for i, node := range nodes {
+		if node.Status == "" {
+			continue
+		}
+		if _, ok := classes[node.Status]; !ok {
+			order = append(order, node.Status)
+		}
+		classes[node.Status] = append(classes[node.Status], fmt.Sprintf("t%d", i))
+	}
+	fills := map[string]string{
+		"succeeded": "#cfc",
+		"failed":    "#fcc",
+		"running":   "#cdf",
+		"uploading": "#cdf",
+		"starting":  "#ffc",
+		"pending":   "#ffc",
+		"unknown":   "#eee",
+	}
+	for _, status := range order {
+		fill, ok := fills[status]
+		if !ok {
+			continue
+		}
+		fmt.Fprintf(w, "  classDef %s fill:%s\n", status, fill)
+		fmt.Fprintf(w, "  class %s %s\n", strings.Join(classes[status], ","), status)
+	}
+}
+
+// mermaidEscape escapes characters which end a quoted Mermaid label.
+func mermaidEscape(s string) string {
+	return strings.ReplaceAll(s, `"`, "#quot;")
+}
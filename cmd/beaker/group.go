@@ -2,6 +2,7 @@ package main
 
 import (
 	"fmt"
+	"sort"
 	"strings"
 
 	"github.com/beaker/client/api"
@@ -19,6 +20,7 @@ func newGroupCommand() *cobra.Command {
 	cmd.AddCommand(newGroupDeleteCommand())
 	cmd.AddCommand(newGroupExperimentsCommand())
 	cmd.AddCommand(newGroupGetCommand())
+	cmd.AddCommand(newGroupMetricsCommand())
 	cmd.AddCommand(newGroupRemoveCommand())
 	cmd.AddCommand(newGroupRenameCommand())
 	cmd.AddCommand(newGroupTasksCommand())
@@ -150,6 +152,148 @@ func newGroupGetCommand() *cobra.Command {
 	}
 }
 
+func newGroupMetricsCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "metrics <group>",
+		Short: "Compare metrics of experiments in a group",
+		Long: `Compare metrics of experiments in a group.
+
+Metrics are taken from the latest job of each task and shown with one row per
+experiment. Metrics of experiments with more than one task are prefixed with
+the task's name, e.g. "eval/accuracy". Use --format=csv or --format=markdown
+to export the table.`,
+		Args: cobra.ExactArgs(1),
+	}
+
+	var sortBy string
+	var ascending bool
+	var metrics []string
+	cmd.Flags().StringVar(&sortBy, "sort", "", "Sort experiments by a metric, highest first")
+	cmd.Flags().BoolVar(&ascending, "ascending", false, "Sort lowest first")
+	cmd.Flags().StringSliceVar(&metrics, "metrics", nil, "Metrics to show, in order, e.g. accuracy,loss")
+
+	cmd.RunE = func(cmd *cobra.Command, args []string) error {
+		experimentIDs, err := beaker.Group(args[0]).Experiments(ctx)
+		if err != nil {
+			return err
+		}
+
+		var rows []experimentMetrics
+		seen := make(map[string]bool)
+		for _, experimentID := range experimentIDs {
+			row, err := getExperimentMetrics(experimentID)
+			if err != nil {
+				return err
+			}
+			rows = append(rows, *row)
+			for metric := range row.Metrics {
+				seen[metric] = true
+			}
+		}
+
+		columns := metrics
+		if len(columns) == 0 {
+			for metric := range seen {
+				columns = append(columns, metric)
+			}
+			sort.Strings(columns)
+		}
+		if sortBy != "" {
+			if !seen[sortBy] {
+				return fmt.Errorf("no experiment has the metric %q", sortBy)
+			}
+			sortExperimentMetrics(rows, sortBy, ascending)
+		}
+		return printExperimentMetrics(rows, columns)
+	}
+	return cmd
+}
+
+// experimentMetrics are the final metrics of an experiment's tasks.
+type experimentMetrics struct {
+	ID         string                 `json:"id"`
+	Experiment string                 `json:"experiment"`
+	Metrics    map[string]interface{} `json:"metrics"`
+}
+
+func getExperimentMetrics(experimentID string) (*experimentMetrics, error) {
+	experiment, err := beaker.Experiment(experimentID).Get(ctx)
+	if err != nil {
+		return nil, err
+	}
+	tasks, err := beaker.Experiment(experimentID).Tasks(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	row := &experimentMetrics{
+		ID:         experiment.ID,
+		Experiment: experiment.ID,
+		Metrics:    make(map[string]interface{}),
+	}
+	if experiment.FullName != "" {
+		row.Experiment = experiment.FullName
+	}
+	for _, task := range tasks {
+		if len(task.Jobs) == 0 {
+			continue
+		}
+		job := task.Jobs[len(task.Jobs)-1] // Use last job.
+		if job.Status.Finalized == nil {
+			continue
+		}
+		results, err := beaker.Job(job.ID).GetResults(ctx)
+		if isNotFound(err) {
+			// Jobs which failed may have no results; their metrics are N/A.
+			continue
+		}
+		if err != nil {
+			return nil, fmt.Errorf("getting results of %s: %w", job.ID, err)
+		}
+
+		prefix := ""
+		if len(tasks) > 1 {
+			prefix = task.Name + "/"
+			if task.Name == "" {
+				prefix = task.ID + "/"
+			}
+		}
+		for metric, value := range results.Metrics {
+			row.Metrics[prefix+metric] = value
+		}
+	}
+	return row, nil
+}
+
+// sortExperimentMetrics sorts experiments by a metric. Experiments without
+// the metric are listed last. Numbers are compared numerically.
+func sortExperimentMetrics(rows []experimentMetrics, metric string, ascending bool) {
+	sort.SliceStable(rows, func(i, j int) bool {
+		a, aOK := rows[i].Metrics[metric]
+		b, bOK := rows[j].Metrics[metric]
+		if !aOK || !bOK {
+			return aOK && !bOK
+		}
+
+		x, xOK := a.(float64)
+		y, yOK := b.(float64)
+		if !xOK || !yOK {
+			// Numbers sort before other values.
+			if xOK != yOK {
+				return xOK
+			}
+			if ascending {
+				return fmt.Sprint(a) < fmt.Sprint(b)
+			}
+			return fmt.Sprint(a) > fmt.Sprint(b)
+		}
+		if ascending {
+			return x < y
+		}
+		return x > y
+	})
+}
+
 func newGroupRemoveCommand() *cobra.Command {
 	return &cobra.Command{
 		Use:   "remove <group> <experiment...>",
@@ -9,6 +9,7 @@ import (
 	"os"
 
 	"github.com/beaker/client/api"
+	"github.com/beaker/client/client"
 	"github.com/docker/distribution/reference"
 	"github.com/docker/docker/api/types"
 	docker "github.com/docker/docker/client"
@@ -70,93 +71,102 @@ func newImageCreateCommand() *cobra.Command {
 			return err
 		}
 
-		docker, err := docker.NewClientWithOpts(
-			docker.FromEnv,
-			docker.WithAPIVersionNegotiation())
-		if err != nil {
-			return fmt.Errorf("failed to create Docker client: %w", err)
-		}
-
-		imageTag := args[0]
-		dockerImage, _, err := docker.ImageInspectWithRaw(ctx, imageTag)
+		image, err := createImage(args[0], name, description, workspace)
 		if err != nil {
 			return err
 		}
 
-		spec := api.ImageSpec{
-			Description: description,
-			ImageID:     dockerImage.ID,
-			ImageTag:    imageTag,
-			Workspace:   workspace,
-		}
-		image, err := beaker.CreateImage(ctx, spec, name)
-		if err != nil {
-			return err
+		if quiet {
+			fmt.Println(image.Ref())
+		} else {
+			fmt.Println("Done.")
 		}
+		return nil
+	}
+	return cmd
+}
 
-		if !quiet {
-			if name == "" {
-				fmt.Printf("Pushing %s as %s ...\n", imageTag, color.BlueString(image.Ref()))
-			} else {
-				fmt.Printf("Pushing %s as %s (%s)...\n", imageTag, color.BlueString(name), image.Ref())
-			}
-		}
+// createImage creates a Beaker image from a local Docker image, pushes the
+// image to Beaker's registry, and commits it.
+func createImage(imageTag, name, description, workspace string) (*client.ImageHandle, error) {
+	docker, err := docker.NewClientWithOpts(
+		docker.FromEnv,
+		docker.WithAPIVersionNegotiation())
+	if err != nil {
+		return nil, fmt.Errorf("failed to create Docker client: %w", err)
+	}
 
-		repo, err := image.Repository(ctx, true)
-		if err != nil {
-			return fmt.Errorf("failed to retrieve credentials for remote repository: %w", err)
-		}
+	dockerImage, _, err := docker.ImageInspectWithRaw(ctx, imageTag)
+	if err != nil {
+		return nil, err
+	}
 
-		// Tag the image to the remote repository.
-		if err := docker.ImageTag(ctx, imageTag, repo.ImageTag); err != nil {
-			return fmt.Errorf("failed to set remote image tag: %w", err)
-		}
-		defer func() {
-			// We ignore the error here intentionally. Cleaning up is best-effort
-			// and we can't do anything to recover if this fails.
-			_, _ = docker.ImageRemove(ctx, repo.ImageTag, types.ImageRemoveOptions{})
-		}()
-
-		authConfig := types.AuthConfig{
-			ServerAddress: repo.Auth.ServerAddress,
-			Username:      repo.Auth.User,
-			Password:      repo.Auth.Password,
-		}
-		authJSON, err := json.Marshal(authConfig)
-		if err != nil {
-			return fmt.Errorf("failed to encode remote repository auth: %w", err)
-		}
-		authStr := base64.URLEncoding.EncodeToString(authJSON)
+	spec := api.ImageSpec{
+		Description: description,
+		ImageID:     dockerImage.ID,
+		ImageTag:    imageTag,
+		Workspace:   workspace,
+	}
+	image, err := beaker.CreateImage(ctx, spec, name)
+	if err != nil {
+		return nil, err
+	}
 
-		r, err := docker.ImagePush(ctx, repo.ImageTag, types.ImagePushOptions{RegistryAuth: authStr})
-		if err != nil {
-			return err
-		}
-		// Display push responses as the Docker CLI would. This also translates remote errors.
-		var stream io.Writer = os.Stdout
-		if quiet {
-			stream = ioutil.Discard
-		}
-		if err := jsonmessage.DisplayJSONMessagesStream(r, stream, 0, false, nil); err != nil {
-			_ = r.Close()
-			return err
-		}
-		if err := r.Close(); err != nil {
-			return err
+	if !quiet {
+		if name == "" {
+			fmt.Printf("Pushing %s as %s ...\n", imageTag, color.BlueString(image.Ref()))
+		} else {
+			fmt.Printf("Pushing %s as %s (%s)...\n", imageTag, color.BlueString(name), image.Ref())
 		}
+	}
 
-		if err := image.Commit(ctx); err != nil {
-			return fmt.Errorf("failed to commit image: %w", err)
-		}
+	repo, err := image.Repository(ctx, true)
+	if err != nil {
+		return nil, fmt.Errorf("failed to retrieve credentials for remote repository: %w", err)
+	}
 
-		if quiet {
-			fmt.Println(image.Ref())
-		} else {
-			fmt.Println("Done.")
-		}
-		return nil
+	// Tag the image to the remote repository.
+	if err := docker.ImageTag(ctx, imageTag, repo.ImageTag); err != nil {
+		return nil, fmt.Errorf("failed to set remote image tag: %w", err)
 	}
-	return cmd
+	defer func() {
+		// We ignore the error here intentionally. Cleaning up is best-effort
+		// and we can't do anything to recover if this fails.
+		_, _ = docker.ImageRemove(ctx, repo.ImageTag, types.ImageRemoveOptions{})
+	}()
+
+	authConfig := types.AuthConfig{
+		ServerAddress: repo.Auth.ServerAddress,
+		Username:      repo.Auth.User,
+		Password:      repo.Auth.Password,
+	}
+	authJSON, err := json.Marshal(authConfig)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode remote repository auth: %w", err)
+	}
+	authStr := base64.URLEncoding.EncodeToString(authJSON)
+
+	r, err := docker.ImagePush(ctx, repo.ImageTag, types.ImagePushOptions{RegistryAuth: authStr})
+	if err != nil {
+		return nil, err
+	}
+	// Display push responses as the Docker CLI would. This also translates remote errors.
+	var stream io.Writer = os.Stdout
+	if quiet {
+		stream = ioutil.Discard
+	}
+	if err := jsonmessage.DisplayJSONMessagesStream(r, stream, 0, false, nil); err != nil {
+		_ = r.Close()
+		return nil, err
+	}
+	if err := r.Close(); err != nil {
+		return nil, err
+	}
+
+	if err := image.Commit(ctx); err != nil {
+		return nil, fmt.Errorf("failed to commit image: %w", err)
+	}
+	return image, nil
 }
 
 func newImageDeleteCommand() *cobra.Command {
@@ -309,3 +319,15 @@ func newImageRenameCommand() *cobra.Command {
 		},
 	}
 }
+
+// removeImage removes an image from the local Docker daemon.
+func removeImage(imageTag string) error {
+	docker, err := docker.NewClientWithOpts(
+		docker.FromEnv,
+		docker.WithAPIVersionNegotiation())
+	if err != nil {
+		return fmt.Errorf("failed to create Docker client: %w", err)
+	}
+	_, err = docker.ImageRemove(ctx, imageTag, types.ImageRemoveOptions{})
+	return err
+}
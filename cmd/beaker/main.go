@@ -36,7 +36,9 @@ var quiet bool
 var format string
 
 const (
-	formatJSON = "json"
+	formatJSON     = "json"
+	formatCSV      = "csv"
+	formatMarkdown = "markdown"
 )
 
 var jsonOut *json.Encoder
@@ -85,6 +87,7 @@ func main() {
 	root.PersistentFlags().StringVar(&format, "format", "", "Output format")
 
 	root.AddCommand(newAccountCommand())
+	root.AddCommand(newApplyCommand())
 	root.AddCommand(newClusterCommand())
 	root.AddCommand(newConfigCommand())
 	root.AddCommand(newDatasetCommand())
@@ -95,6 +98,7 @@ func main() {
 	root.AddCommand(newJobCommand())
 	root.AddCommand(newNodeCommand())
 	root.AddCommand(newOrganizationCommand())
+	root.AddCommand(newPlanCommand())
 	root.AddCommand(newSecretCommand())
 	root.AddCommand(newSessionCommand())
 	root.AddCommand(newWorkspaceCommand())
@@ -307,6 +311,33 @@ func confirm(prompt string) (bool, error) {
 	return false, scanner.Err()
 }
 
+// choose prompts the user to pick one of n numbered options.
+// Returns the zero-based index of the chosen option.
+func choose(prompt string, n int) (int, error) {
+	fmt.Printf("%s [1-%d]: ", prompt, n)
+	scanner := bufio.NewScanner(os.Stdin)
+	for scanner.Scan() {
+		input := strings.TrimSpace(scanner.Text())
+		if i, err := strconv.Atoi(input); err == nil && i >= 1 && i <= n {
+			return i - 1, nil
+		}
+		fmt.Printf("Please type a number between 1 and %d: ", n)
+	}
+	if err := scanner.Err(); err != nil {
+		return 0, err
+	}
+	return 0, errors.New("no option selected")
+}
+
+// isInteractive returns true if standard input is a terminal.
+func isInteractive() bool {
+	info, err := os.Stdin.Stat()
+	if err != nil {
+		return false
+	}
+	return info.Mode()&os.ModeCharDevice != 0
+}
+
 // Prompt the user for input.
 func prompt(prompt string) string {
 	fmt.Print(prompt, ": ")
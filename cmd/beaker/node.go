@@ -1,7 +1,15 @@
 package main
 
 import (
+	"context"
+	"fmt"
+	"os"
+	"sort"
+	"time"
+
 	"github.com/beaker/client/api"
+	"github.com/beaker/client/client"
+	"github.com/fatih/color"
 	"github.com/spf13/cobra"
 )
 
@@ -12,7 +20,9 @@ func newNodeCommand() *cobra.Command {
 	}
 	cmd.AddCommand(newNodeCordonCommand())
 	cmd.AddCommand(newNodeDeleteCommand())
+	cmd.AddCommand(newNodeDrainCommand())
 	cmd.AddCommand(newNodeGetCommand())
+	cmd.AddCommand(newNodeListCommand())
 	cmd.AddCommand(newNodeUncordonCommand())
 	return cmd
 }
@@ -42,6 +52,119 @@ func newNodeDeleteCommand() *cobra.Command {
 	}
 }
 
+func newNodeDrainCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "drain <node>",
+		Short: "Cordon a node and wait for its jobs to finish",
+		Long: `Cordon a node and wait for its jobs to finish.
+
+Once drained, the node can be taken down for maintenance. Run "uncordon" to
+return it to service.`,
+		Args: cobra.ExactArgs(1),
+	}
+
+	var timeout time.Duration
+	var stopSessions bool
+	var force bool
+	cmd.Flags().DurationVar(&timeout, "timeout", 0, "Maximum time to wait e.g. 2h. Waits indefinitely by default.")
+	cmd.Flags().BoolVar(&stopSessions, "stop-sessions", false, "Stop sessions running on the node")
+	cmd.Flags().BoolVar(&force, "force", false, "Stop all jobs running on the node, including executions")
+
+	cmd.RunE = func(cmd *cobra.Command, args []string) error {
+		node := args[0]
+		cordoned := true
+		if err := beaker.Node(node).Patch(ctx, &api.NodePatchSpec{
+			Cordoned: &cordoned,
+		}); err != nil {
+			return err
+		}
+		if !quiet {
+			fmt.Printf("Cordoned node %s\n", color.BlueString(node))
+		}
+
+		opts := client.ListJobOpts{
+			Node:      &node,
+			Finalized: api.BoolPtr(false),
+		}
+		jobs, err := listJobs(opts)
+		if err != nil {
+			return err
+		}
+		if len(jobs) == 0 {
+			if !quiet {
+				fmt.Println("Node is drained")
+			}
+			return nil
+		}
+		if !quiet {
+			if err := printJobs(jobs); err != nil {
+				return err
+			}
+			// Flush the table before printing progress.
+			if err := tableOut.Flush(); err != nil {
+				return err
+			}
+		}
+
+		if force {
+			confirmed, err := confirm(fmt.Sprintf(
+				"Are you sure you want to stop all %d jobs on this node?", len(jobs)))
+			if err != nil {
+				return err
+			}
+			if !confirmed {
+				return nil
+			}
+		}
+
+		if force || stopSessions {
+			var failed int
+			message := "Stopped by 'beaker node drain'"
+			for _, job := range jobs {
+				if !force && job.Kind != api.JobKindSession {
+					continue
+				}
+				if _, err := beaker.Job(job.ID).Patch(ctx, api.JobPatch{
+					Status: &api.JobStatusUpdate{Canceled: true, Message: &message},
+				}); err != nil {
+					// Stop as many jobs as possible, reporting failures at the end.
+					fmt.Fprintln(os.Stderr, color.RedString("Error:"), job.ID, err)
+					failed++
+					continue
+				}
+				if !quiet {
+					fmt.Printf("Stopped %s %s\n", job.Kind, color.BlueString(job.ID))
+				}
+			}
+			if failed != 0 {
+				return fmt.Errorf("failed to stop %d jobs", failed)
+			}
+		}
+
+		ctx := ctx
+		if timeout > 0 {
+			var cancel context.CancelFunc
+			ctx, cancel = context.WithDeadline(ctx, time.Now().Add(timeout))
+			defer cancel()
+		}
+		drained := func(ctx context.Context) (bool, error) {
+			jobs, err := listJobs(opts)
+			if err != nil {
+				return false, err
+			}
+			return len(jobs) == 0, nil
+		}
+		if err := await(ctx, "Waiting for jobs to finish", drained, 10*time.Second); err != nil {
+			return fmt.Errorf("node not drained: %w", err)
+		}
+		if !quiet {
+			fmt.Println("Node is drained")
+		}
+		return nil
+	}
+	return cmd
+}
+
 func newNodeGetCommand() *cobra.Command {
 	return &cobra.Command{
 		Use:     "get <node...>",
@@ -62,6 +185,147 @@ func newNodeGetCommand() *cobra.Command {
 	}
 }
 
+func newNodeListCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "list <cluster>",
+		Short: "List nodes in a cluster with their free resources",
+		Long: `List nodes in a cluster with their free resources.
+
+Free resources are each node's limits minus the limits of jobs running on it.`,
+		Args: cobra.ExactArgs(1),
+	}
+
+	var sortBy string
+	cmd.Flags().StringVar(&sortBy, "sort", "hostname", "Sort by one of: hostname, cpus, gpus, memory, jobs")
+
+	cmd.RunE = func(cmd *cobra.Command, args []string) error {
+		nodes, err := getNodeUtilization(args[0], "")
+		if err != nil {
+			return err
+		}
+		if err := sortNodeUtilization(nodes, sortBy); err != nil {
+			return err
+		}
+		return printNodeUtilization(nodes)
+	}
+	return cmd
+}
+
+// nodeUtilization describes the resources of a node which aren't reserved
+// by running jobs.
+type nodeUtilization struct {
+	api.Node
+
+	// Free resources are the node's limits minus the limits of its jobs.
+	// Nil if the node's limits are unknown.
+	Free *api.NodeResources `json:"free,omitempty"`
+
+	// GPUs assigned to jobs on the node.
+	UsedGPUs []string `json:"usedGpus,omitempty"`
+
+	// Number of unfinalized jobs assigned to the node.
+	Jobs int `json:"jobs"`
+}
+
+// freeNode returns a copy of the node limited to its free resources.
+func (u *nodeUtilization) freeNode() *api.Node {
+	node := u.Node
+	node.Limits = u.Free
+	return &node
+}
+
+// getNodeUtilization calculates the free resources of each node in a cluster.
+// If set, the job to ignore is excluded from the calculation.
+func getNodeUtilization(cluster string, ignoreJob string) ([]nodeUtilization, error) {
+	nodes, err := beaker.Cluster(cluster).ListClusterNodes(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("couldn't list cluster nodes: %w", err)
+	}
+
+	utilization := make([]nodeUtilization, len(nodes))
+	byID := make(map[string]*nodeUtilization, len(nodes))
+	for i, node := range nodes {
+		u := nodeUtilization{Node: node}
+		if node.Limits != nil {
+			free := *node.Limits
+			if free.Memory != nil {
+				memory := *free.Memory
+				free.Memory = &memory
+			}
+			u.Free = &free
+		}
+		utilization[i] = u
+		byID[node.ID] = &utilization[i]
+	}
+
+	jobs, err := listJobs(client.ListJobOpts{
+		Cluster:   cluster,
+		Finalized: api.BoolPtr(false),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("couldn't list cluster jobs: %w", err)
+	}
+
+	// Subtract each running job from its node's capacity.
+	for _, job := range jobs {
+		u, ok := byID[job.Node]
+		if !ok || job.ID == ignoreJob {
+			continue
+		}
+		u.Jobs++
+
+		// Ignore jobs which haven't fully scheduled yet.
+		if job.Limits == nil {
+			continue
+		}
+		u.UsedGPUs = append(u.UsedGPUs, job.Limits.GPUs...)
+		if u.Free == nil {
+			continue
+		}
+		u.Free.CPUCount -= job.Limits.CPUCount
+		u.Free.GPUCount -= len(job.Limits.GPUs)
+		if u.Free.Memory != nil && job.Limits.Memory != nil {
+			u.Free.Memory.Sub(*job.Limits.Memory)
+		}
+	}
+	return utilization, nil
+}
+
+// sortNodeUtilization sorts nodes by hostname or by descending free resources.
+func sortNodeUtilization(nodes []nodeUtilization, by string) error {
+	free := func(u nodeUtilization) api.NodeResources {
+		if u.Free == nil {
+			return api.NodeResources{}
+		}
+		return *u.Free
+	}
+
+	var less func(a, b nodeUtilization) bool
+	switch by {
+	case "hostname":
+		less = func(a, b nodeUtilization) bool { return a.Hostname < b.Hostname }
+	case "cpus":
+		less = func(a, b nodeUtilization) bool { return free(a).CPUCount > free(b).CPUCount }
+	case "gpus":
+		less = func(a, b nodeUtilization) bool { return free(a).GPUCount > free(b).GPUCount }
+	case "memory":
+		less = func(a, b nodeUtilization) bool {
+			am, bm := free(a).Memory, free(b).Memory
+			if am == nil || bm == nil {
+				return am != nil
+			}
+			return am.Cmp(*bm) > 0
+		}
+	case "jobs":
+		less = func(a, b nodeUtilization) bool { return a.Jobs > b.Jobs }
+	default:
+		return fmt.Errorf("invalid sort field: %s", by)
+	}
+
+	sort.SliceStable(nodes, func(i, j int) bool { return less(nodes[i], nodes[j]) })
+	return nil
+}
+
 func newNodeUncordonCommand() *cobra.Command {
 	return &cobra.Command{
 		Use:   "uncordon <node>",
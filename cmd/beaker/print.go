@@ -1,18 +1,34 @@
 package main
 
 import (
+	"encoding/csv"
 	"fmt"
+	"os"
+	"sort"
 	"strconv"
 	"strings"
 	"time"
 
+	"github.com/allenai/beaker/config"
+	"github.com/allenai/bytefmt"
 	"github.com/beaker/client/api"
+	"github.com/fatih/color"
+	"github.com/moby/term"
 )
 
 func printJSON(v interface{}) error {
 	return jsonOut.Encode(v)
 }
 
+func printCSV(rows [][]string) error {
+	w := csv.NewWriter(os.Stdout)
+	if err := w.WriteAll(rows); err != nil {
+		return err
+	}
+	w.Flush()
+	return w.Error()
+}
+
 func printTableRow(cells ...interface{}) error {
 	var cellStrings []string
 	for _, cell := range cells {
@@ -43,6 +59,85 @@ func printTableRow(cells ...interface{}) error {
 	return err
 }
 
+func printClusterQueue(queue []queuedJob) error {
+	switch format {
+	case formatJSON:
+		return printJSON(queue)
+	default:
+		if err := printTableRow(
+			"POSITION",
+			"ID",
+			"KIND",
+			"NAME",
+			"AUTHOR",
+			"PRIORITY",
+			"REQUESTS",
+			"AGE",
+		); err != nil {
+			return err
+		}
+		for _, job := range queue {
+			if err := printTableRow(
+				job.Position,
+				job.ID,
+				job.Kind,
+				job.Name,
+				job.Author.Name,
+				job.Priority,
+				resourceRequestString(job.Requests),
+				queueAge(job.Job),
+			); err != nil {
+				return err
+			}
+		}
+		if err := tableOut.Flush(); err != nil {
+			return err
+		}
+
+		for _, job := range queue {
+			if job.Blockers == nil {
+				continue
+			}
+			fmt.Printf("\nYour %s %s is at position %d of %d.\n",
+				job.Kind, color.BlueString(job.ID), job.Position, len(queue))
+			if len(job.FitsOn) > 0 {
+				fmt.Printf("It fits on %s.\n", strings.Join(job.FitsOn, ", "))
+			}
+			if job.Ahead != nil {
+				fmt.Printf("%d jobs ahead of it can run on the same nodes.\n", *job.Ahead)
+			}
+			if job.EstimatedWait != nil {
+				fmt.Printf("Estimated wait: %s\n", queueWaitString(*job.EstimatedWait))
+			}
+
+			hosts := make([]string, 0, len(job.Blockers))
+			for host := range job.Blockers {
+				hosts = append(hosts, host)
+			}
+			sort.Strings(hosts)
+			if len(hosts) > 0 {
+				fmt.Println("It can't be placed on these nodes because:")
+			}
+			for _, host := range hosts {
+				fmt.Printf("    %s: %s\n", host, job.Blockers[host])
+			}
+		}
+		return nil
+	}
+}
+
+// queueWaitString rounds an estimated wait to avoid implying precision.
+func queueWaitString(wait time.Duration) string {
+	switch {
+	case wait < time.Minute:
+		return "less than a minute"
+	case wait < time.Hour:
+		return fmt.Sprintf("about %d minutes", int(wait.Round(time.Minute)/time.Minute))
+	default:
+		return fmt.Sprintf("about %.1f hours", wait.Hours())
+	}
+}
+
 func printClusters(clusters []api.Cluster) error {
 	switch format {
 	case formatJSON:
@@ -170,6 +265,180 @@ func printExperiments(experiments []api.Experiment) error {
 	}
 }
 
+type experimentDiffRow struct {
+	Task   string      `json:"task"`
+	Key    string      `json:"key,omitempty"`
+	First  interface{} `json:"first"`
+	Second interface{} `json:"second"`
+}
+
+func printExperimentDiff(a, b *experimentSummary, sideBySide bool, contextLines int) error {
+	specDiff := diffLines(a.Spec, b.Spec)
+
+	// Match tasks by name in the order they first appear.
+	var tasks []string
+	aTasks := make(map[string]taskSummary)
+	bTasks := make(map[string]taskSummary)
+	for _, t := range a.Tasks {
+		aTasks[t.Name] = t
+		tasks = append(tasks, t.Name)
+	}
+	for _, t := range b.Tasks {
+		bTasks[t.Name] = t
+		if _, ok := aTasks[t.Name]; !ok {
+			tasks = append(tasks, t.Name)
+		}
+	}
+
+	var images, datasets, metrics []experimentDiffRow
+	for _, task := range tasks {
+		at, bt := aTasks[task], bTasks[task]
+		if at.Image != bt.Image {
+			images = append(images, experimentDiffRow{Task: task, First: at.Image, Second: bt.Image})
+		}
+
+		for _, path := range sortedKeys(at.Datasets, bt.Datasets) {
+			if at.Datasets[path] != bt.Datasets[path] {
+				datasets = append(datasets, experimentDiffRow{
+					Task:   task,
+					Key:    path,
+					First:  at.Datasets[path],
+					Second: bt.Datasets[path],
+				})
+			}
+		}
+
+		var names []string
+		for name := range at.Metrics {
+			names = append(names, name)
+		}
+		for name := range bt.Metrics {
+			if _, ok := at.Metrics[name]; !ok {
+				names = append(names, name)
+			}
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			metrics = append(metrics, experimentDiffRow{
+				Task:   task,
+				Key:    name,
+				First:  at.Metrics[name],
+				Second: bt.Metrics[name],
+			})
+		}
+	}
+
+	switch format {
+	case formatJSON:
+		var spec []string
+		for _, line := range specDiff {
+			spec = append(spec, string(line.Op)+line.Text)
+		}
+		return printJSON(struct {
+			First    string              `json:"first"`
+			Second   string              `json:"second"`
+			Spec     []string            `json:"spec"`
+			Images   []experimentDiffRow `json:"images"`
+			Datasets []experimentDiffRow `json:"datasets"`
+			Metrics  []experimentDiffRow `json:"metrics"`
+		}{a.Name, b.Name, spec, images, datasets, metrics})
+	default:
+		fmt.Println(color.New(color.Bold).Sprint("Spec"))
+		switch {
+		case !hasChanges(specDiff):
+			fmt.Println("No differences")
+		case sideBySide:
+			width := 160
+			if ws, err := term.GetWinsize(os.Stdout.Fd()); err == nil && ws.Width > 0 {
+				width = int(ws.Width)
+			}
+			printSideBySideDiff(os.Stdout, specDiff, width)
+		default:
+			printUnifiedDiff(os.Stdout, a.Name, b.Name, specDiff, contextLines)
+		}
+
+		sections := []struct {
+			title string
+			key   string
+			rows  []experimentDiffRow
+		}{
+			{"Images", "", images},
+			{"Datasets", "MOUNT", datasets},
+			{"Metrics", "METRIC", metrics},
+		}
+		for _, section := range sections {
+			fmt.Println()
+			fmt.Println(color.New(color.Bold).Sprint(section.title))
+			if len(section.rows) == 0 {
+				if section.key == "METRIC" {
+					fmt.Println("No metrics")
+				} else {
+					fmt.Println("No differences")
+				}
+				continue
+			}
+
+			header := []interface{}{"TASK"}
+			if section.key != "" {
+				header = append(header, section.key)
+			}
+			header = append(header, a.Name, b.Name)
+			if section.key == "METRIC" {
+				header = append(header, "DELTA")
+			}
+			if err := printTableRow(header...); err != nil {
+				return err
+			}
+			for _, r := range section.rows {
+				row := []interface{}{r.Task}
+				if section.key != "" {
+					row = append(row, r.Key)
+				}
+				row = append(row, r.First, r.Second)
+				if section.key == "METRIC" {
+					row = append(row, metricDelta(r.First, r.Second))
+				}
+				if err := printTableRow(row...); err != nil {
+					return err
+				}
+			}
+			if err := tableOut.Flush(); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+}
+
+// metricDelta returns the difference between two numeric metrics.
+func metricDelta(a, b interface{}) interface{} {
+	x, ok := a.(float64)
+	if !ok {
+		return nil
+	}
+	y, ok := b.(float64)
+	if !ok {
+		return nil
+	}
+	return fmt.Sprintf("%+g", y-x)
+}
+
+// sortedKeys returns the keys of all maps in order.
+func sortedKeys(maps ...map[string]string) []string {
+	seen := make(map[string]bool)
+	var keys []string
+	for _, m := range maps {
+		for k := range m {
+			if !seen[k] {
+				seen[k] = true
+				keys = append(keys, k)
+			}
+		}
+	}
+	sort.Strings(keys)
+	return keys
+}
+
 func printGroups(groups []api.Group) error {
 	switch format {
 	case formatJSON:
@@ -244,7 +513,134 @@ func jobDuration(job api.Job) time.Duration {
 	return duration
 }
 
+func printJobCosts(costs []jobCostGroup) error {
+	switch format {
+	case formatJSON:
+		return printJSON(costs)
+	case formatCSV:
+		rows := [][]string{{"group", "jobs", "gpu_hours", "cost_usd"}}
+		for _, c := range costs {
+			rows = append(rows, []string{
+				c.Group,
+				strconv.Itoa(c.Jobs),
+				strconv.FormatFloat(c.GPUHours, 'f', 2, 64),
+				strconv.FormatFloat(c.Cost, 'f', 2, 64),
+			})
+		}
+		return printCSV(rows)
+	default:
+		if err := printTableRow(
+			"GROUP",
+			"JOBS",
+			"GPU HOURS",
+			"COST",
+		); err != nil {
+			return err
+		}
+		var total jobCostGroup
+		for _, c := range costs {
+			total.Jobs += c.Jobs
+			total.GPUHours += c.GPUHours
+			total.Cost += c.Cost
+			if err := printTableRow(
+				c.Group,
+				c.Jobs,
+				fmt.Sprintf("%.1f", c.GPUHours),
+				fmt.Sprintf("$%.2f", c.Cost),
+			); err != nil {
+				return err
+			}
+		}
+		return printTableRow(
+			"TOTAL",
+			total.Jobs,
+			fmt.Sprintf("%.1f", total.GPUHours),
+			fmt.Sprintf("$%.2f", total.Cost),
+		)
+	}
+}
+
+func printExperimentMetrics(rows []experimentMetrics, metrics []string) error {
+	cell := func(v interface{}) string {
+		switch v := v.(type) {
+		case nil:
+			return ""
+		case float64:
+			return strconv.FormatFloat(v, 'g', -1, 64)
+		default:
+			return fmt.Sprint(v)
+		}
+	}
+
+	switch format {
+	case formatJSON:
+		filtered := make([]experimentMetrics, len(rows))
+		for i, row := range rows {
+			filtered[i] = row
+			filtered[i].Metrics = make(map[string]interface{}, len(metrics))
+			for _, metric := range metrics {
+				if v, ok := row.Metrics[metric]; ok {
+					filtered[i].Metrics[metric] = v
+				}
+			}
+		}
+		return printJSON(filtered)
+	case formatCSV:
+		records := [][]string{append([]string{"experiment"}, metrics...)}
+		for _, row := range rows {
+			record := []string{row.Experiment}
+			for _, metric := range metrics {
+				record = append(record, cell(row.Metrics[metric]))
+			}
+			records = append(records, record)
+		}
+		return printCSV(records)
+	case formatMarkdown:
+		escape := strings.NewReplacer("|", "\\|", "\n", " ").Replace
+		header := []string{"Experiment"}
+		align := []string{":--"}
+		for _, metric := range metrics {
+			header = append(header, escape(metric))
+			align = append(align, "--:")
+		}
+		fmt.Printf("| %s |\n", strings.Join(header, " | "))
+		fmt.Printf("| %s |\n", strings.Join(align, " | "))
+		for _, row := range rows {
+			cells := []string{escape(row.Experiment)}
+			for _, metric := range metrics {
+				cells = append(cells, escape(cell(row.Metrics[metric])))
+			}
+			fmt.Printf("| %s |\n", strings.Join(cells, " | "))
+		}
+		return nil
+	default:
+		header := []interface{}{"EXPERIMENT"}
+		for _, metric := range metrics {
+			header = append(header, strings.ToUpper(metric))
+		}
+		if err := printTableRow(header...); err != nil {
+			return err
+		}
+		for _, row := range rows {
+			cells := []interface{}{row.Experiment}
+			for _, metric := range metrics {
+				cells = append(cells, cell(row.Metrics[metric]))
+			}
+			if err := printTableRow(cells...); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+}
+
 func printJobs(jobs []api.Job) error {
+	return printJobsOnNode(jobs, "", nil)
+}
+
+// printJobsOnNode prints jobs, showing the GPUs of jobs on the given node by
+// the index of their device.
+func printJobsOnNode(jobs []api.Job, node string, devices []gpuDevice) error {
 	switch format {
 	case formatJSON:
 		return printJSON(jobs)
@@ -262,6 +658,7 @@ func printJobs(jobs []api.Job) error {
 		); err != nil {
 			return err
 		}
+
 		for _, job := range jobs {
 			duration := jobDuration(job)
 
@@ -273,6 +670,9 @@ func printJobs(jobs []api.Job) error {
 			var gpus string
 			if job.Limits != nil {
 				gpus = strconv.Itoa(len(job.Limits.GPUs))
+				if node != "" && job.Node == node && len(job.Limits.GPUs) > 0 {
+					gpus += " (" + strings.Join(gpuIndices(devices, job.Limits.GPUs), ",") + ")"
+				}
 			}
 
 			if err := printTableRow(
@@ -357,6 +757,121 @@ func printNodes(nodes []api.Node) error {
 	}
 }
 
+func printNodeFits(fits []nodeFit) error {
+	switch format {
+	case formatJSON:
+		return printJSON(fits)
+	default:
+		if err := printTableRow(
+			"CLUSTER",
+			"HOSTNAME",
+			"FREE CPUS",
+			"FREE GPUS",
+			"GPU TYPE",
+			"FREE MEMORY",
+			"JOBS",
+			"FIT",
+		); err != nil {
+			return err
+		}
+		for _, fit := range fits {
+			var free api.NodeResources
+			if fit.Free != nil {
+				free = *fit.Free
+			}
+			result := "yes"
+			if !fit.Fits {
+				result = "no: " + fit.Reason
+			}
+			if err := printTableRow(
+				fit.Cluster,
+				fit.Hostname,
+				free.CPUCount,
+				free.GPUCount,
+				free.GPUType,
+				free.Memory,
+				fit.Jobs,
+				result,
+			); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+}
+
+func printNodeUtilization(nodes []nodeUtilization) error {
+	switch format {
+	case formatJSON:
+		return printJSON(nodes)
+	default:
+		if err := printTableRow(
+			"ID",
+			"HOSTNAME",
+			"FREE CPUS",
+			"FREE GPUS",
+			"GPU TYPE",
+			"FREE MEMORY",
+			"JOBS",
+			"STATUS",
+		); err != nil {
+			return err
+		}
+
+		var total api.NodeResources
+		var totalJobs int
+		for _, node := range nodes {
+			status := "ok"
+			if node.Cordoned != nil {
+				status = "cordoned"
+			}
+
+			var free api.NodeResources
+			if node.Free != nil {
+				free = *node.Free
+			}
+			if node.Cordoned == nil {
+				total.CPUCount += free.CPUCount
+				total.GPUCount += free.GPUCount
+				if free.Memory != nil {
+					if total.Memory == nil {
+						total.Memory = bytefmt.New(0, bytefmt.Binary)
+					}
+					total.Memory.Add(*free.Memory)
+				}
+			}
+			totalJobs += node.Jobs
+
+			if err := printTableRow(
+				node.ID,
+				node.Hostname,
+				free.CPUCount,
+				free.GPUCount,
+				free.GPUType,
+				free.Memory,
+				node.Jobs,
+				status,
+			); err != nil {
+				return err
+			}
+		}
+
+		// Cordoned nodes can't run new jobs, so they're left out of the totals.
+		// HACK printTableRow prints "N/A" instead of an empty string,
+		// which we don't want, so we pass a single space instead.
+		return printTableRow(
+			"TOTAL",
+			" ",
+			total.CPUCount,
+			total.GPUCount,
+			" ",
+			total.Memory,
+			totalJobs,
+			" ",
+		)
+	}
+}
+
 func printOrganizations(orgs []api.Organization) error {
 	switch format {
 	case formatJSON:
@@ -403,6 +918,45 @@ func printSecrets(secrets []api.Secret) error {
 	}
 }
 
+func printSessionPresets(presets map[string]config.SessionPreset) error {
+	switch format {
+	case formatJSON:
+		return printJSON(presets)
+	default:
+		if err := printTableRow(
+			"NAME",
+			"IMAGE",
+			"WORKSPACE",
+			"RESOURCES",
+		); err != nil {
+			return err
+		}
+
+		names := make([]string, 0, len(presets))
+		for name := range presets {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			preset := presets[name]
+
+			var memory *bytefmt.Size
+			if preset.Memory != "" {
+				memory, _ = bytefmt.Parse(preset.Memory)
+			}
+			if err := printTableRow(
+				name,
+				preset.Image,
+				preset.Workspace,
+				resourceString(preset.GPUs, preset.CPUs, memory),
+			); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+}
+
 func printTasks(tasks []api.Task) error {
 	switch format {
 	case formatJSON:
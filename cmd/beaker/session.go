@@ -1,12 +1,18 @@
 package main
 
 import (
+	"bufio"
 	"context"
 	"errors"
 	"fmt"
+	"io"
+	"net/http"
 	"os"
+	"path"
+	"path/filepath"
 	"strconv"
 	"strings"
+	"text/tabwriter"
 	"time"
 
 	"github.com/allenai/beaker/config"
@@ -17,6 +23,7 @@ import (
 	"github.com/beaker/runtime/docker"
 	"github.com/fatih/color"
 	"github.com/spf13/cobra"
+	"gopkg.in/yaml.v3"
 )
 
 const defaultImage = "beaker://ai2/cuda11.2-ubuntu20.04"
@@ -33,6 +40,11 @@ func newSessionCommand() *cobra.Command {
 	cmd.AddCommand(newSessionDescribeCommand())
 	cmd.AddCommand(newSessionImagesCommand())
 	cmd.AddCommand(newSessionListCommand())
+	cmd.AddCommand(newSessionPresetCommand())
+	cmd.AddCommand(newSessionReapCommand())
+	cmd.AddCommand(newSessionReplayCommand())
+	cmd.AddCommand(newSessionSnapshotCommand())
+	cmd.AddCommand(newSessionStatsCommand())
 	cmd.AddCommand(newSessionStopCommand())
 	return cmd
 }
@@ -45,10 +57,18 @@ func newSessionAttachCommand() *cobra.Command {
 	}
 
 	var session string
+	var record string
 	cmd.Flags().StringVar(&session, "session", "", "Target session. Defaults to the running session.")
+	cmd.Flags().StringVar(&record, "record", "", "Record the session's output to an asciinema cast file. Requires a terminal.")
 
 	cmd.RunE = func(cmd *cobra.Command, args []string) error {
-		container, _, err := findRunningSessionContainer(session)
+		if record != "" {
+			if err := checkCanRecord(); err != nil {
+				return err
+			}
+		}
+
+		container, info, err := findRunningSessionContainer(session)
 		if err != nil {
 			return err
 		}
@@ -59,6 +79,15 @@ func newSessionAttachCommand() *cobra.Command {
 		}
 		defer resp.Close()
 
+		if record != "" {
+			rec, err := newCastRecorder(record, "Beaker session "+info.ID)
+			if err != nil {
+				return err
+			}
+			defer rec.Close()
+			resp.Reader = bufio.NewReader(io.TeeReader(resp.Reader, rec))
+		}
+
 		return handleAttachErr(container.Stream(ctx, resp))
 	}
 	return cmd
@@ -75,21 +104,21 @@ To pass flags, use "--" e.g. "create -- ls -l"`,
 		Args: cobra.ArbitraryArgs,
 	}
 
-	var image string
 	var name string
 	var node string
-	var workspace string
+	var preset string
 	var saveImage bool
 	var noUpdateDefaultImage bool
-	cmd.Flags().StringVarP(
-		&image,
-		"image",
-		"i",
-		defaultImage,
-		"Base image to run, may be a Beaker or Docker image. Uses 'default_image' from the Beaker configuration if set.")
+	var gpuIDs []string
+	var gpuType string
+	var record string
 	cmd.Flags().StringVarP(&name, "name", "n", "", "Assign a name to the session")
 	cmd.Flags().StringVar(&node, "node", "", "Node that the session will run on. Defaults to current node.")
-	cmd.Flags().StringVarP(&workspace, "workspace", "w", "", "Workspace where the session will be placed")
+	cmd.Flags().StringVar(
+		&preset,
+		"preset",
+		"",
+		"Session preset to create the session from. Flags set explicitly take precedence over the preset.")
 	cmd.Flags().BoolVarP(
 		&saveImage,
 		"save-image",
@@ -101,85 +130,108 @@ To pass flags, use "--" e.g. "create -- ls -l"`,
 		"no-update-default-image",
 		false,
 		"Do not update the default image when using --save-image.")
-
-	var secretEnv map[string]string
-	var secretMount map[string]string
-	cmd.Flags().StringToStringVar(
-		&secretEnv,
-		"secret-env",
-		map[string]string{},
-		"Secret environment variables in the format <variable>=<secret name>")
-	cmd.Flags().StringToStringVar(
-		&secretMount,
-		"secret-mount",
-		map[string]string{},
-		"Secret file mounts in the format <secret name>=<file path> e.g. SECRET=/secret")
-
-	var cpus float64
-	var gpus int
-	var memory string
-	var sharedMemory string
-	var ports []string
-	cmd.Flags().Float64Var(&cpus, "cpus", 0, "Minimum CPU cores to reserve, e.g. 7.5")
-	cmd.Flags().IntVar(&gpus, "gpus", 0, "Minimum number of GPUs to reserve")
-	cmd.Flags().StringVar(&memory, "memory", "", "Minimum memory to reserve, e.g. 6.5GiB")
-	cmd.Flags().StringVar(&sharedMemory, "shared-memory", "", "Shared memory (size of /dev/shm), e.g. 1GiB")
 	cmd.Flags().StringSliceVar(
-		&ports,
-		"port",
-		[]string{},
-		"TCP container ports to expose. Each will be assigned a random, ephemeral port on the host.",
-	)
+		&gpuIDs,
+		"gpu-ids",
+		nil,
+		"Indices or UUIDs of GPUs the session must be assigned e.g. 0,2. Specific GPUs can't be reserved, "+
+			"so the session is canceled if it's assigned others. Only available on the current node.")
+	cmd.Flags().StringVar(&gpuType, "gpu-type", "", "Type of GPU to reserve e.g. A100")
+	cmd.Flags().StringVar(&record, "record", "", "Record the session's output to an asciinema cast file. Requires a terminal.")
+	flags := addSessionFlags(cmd)
 
 	cmd.RunE = func(cmd *cobra.Command, args []string) error {
+		if record != "" {
+			if err := checkCanRecord(); err != nil {
+				return err
+			}
+		}
+
 		rt, err := docker.NewRuntime()
 		if err != nil {
 			return fmt.Errorf("couldn't initialize container runtime: %w", err)
 		}
 
+		if preset != "" {
+			presets, err := config.ReadSessionPresets(config.GetSessionPresetsPath())
+			if err != nil {
+				return err
+			}
+			p, ok := presets[preset]
+			if !ok {
+				return fmt.Errorf("session preset not found: %s", preset)
+			}
+			if err := flags.applyPreset(cmd, p); err != nil {
+				return err
+			}
+		}
+
 		if node == "" {
 			if node, err = getCurrentNode(); err != nil {
 				return fmt.Errorf("failed to detect node; use --node flag: %w", err)
 			}
 		}
 
+		var gpus *gpuRequest
+		if len(gpuIDs) > 0 || gpuType != "" {
+			gpus, err = newGPURequest(cmd, flags, node, gpuIDs, gpuType)
+			if err != nil {
+				return err
+			}
+		}
+
 		var memSize *bytefmt.Size
-		if memory != "" {
-			if memSize, err = bytefmt.Parse(memory); err != nil {
+		if flags.memory != "" {
+			if memSize, err = bytefmt.Parse(flags.memory); err != nil {
 				return fmt.Errorf("invalid value for --memory: %w", err)
 			}
 		}
 
 		var sharedMemSize *bytefmt.Size
-		if sharedMemory != "" {
-			if sharedMemSize, err = bytefmt.Parse(sharedMemory); err != nil {
+		if flags.sharedMemory != "" {
+			if sharedMemSize, err = bytefmt.Parse(flags.sharedMemory); err != nil {
 				return fmt.Errorf("invalid value for --shared-memory: %w", err)
 			}
 		}
 
-		if image == defaultImage && beakerConfig.DefaultImage != "" {
+		if flags.image == defaultImage && beakerConfig.DefaultImage != "" {
 			fmt.Printf("Defaulting to image %s\n", color.BlueString(beakerConfig.DefaultImage))
-			image = beakerConfig.DefaultImage
+			flags.image = beakerConfig.DefaultImage
 		}
-		imageSource, err := getImageSource(image)
+		imageSource, err := getImageSource(flags.image)
 		if err != nil {
 			return err
 		}
 
-		if workspace, err = ensureWorkspace(workspace); err != nil {
+		if flags.workspace, err = ensureWorkspace(flags.workspace); err != nil {
+			return err
+		}
+
+		env, err := parseEnv(flags.envFile, flags.env)
+		if err != nil {
 			return err
 		}
 
 		var envVars []api.EnvironmentVariable
-		for k, v := range secretEnv {
+		for k, v := range flags.secretEnv {
+			if _, ok := env[k]; ok {
+				return fmt.Errorf("environment variable %s is set by both --env and --secret-env", k)
+			}
 			envVars = append(envVars, api.EnvironmentVariable{
 				Name:   k,
 				Secret: v,
 			})
 		}
+		for k, v := range env {
+			v := v
+			envVars = append(envVars, api.EnvironmentVariable{
+				Name:  k,
+				Value: &v,
+			})
+		}
 
 		var mounts []api.DataMount
-		for k, v := range secretMount {
+		for k, v := range flags.secretMount {
 			mounts = append(mounts, api.DataMount{
 				MountPath: v,
 				Source: api.DataSource{
@@ -187,9 +239,40 @@ To pass flags, use "--" e.g. "create -- ls -l"`,
 				},
 			})
 		}
+		for k, v := range flags.datasets {
+			mounts = append(mounts, api.DataMount{
+				MountPath: v,
+				Source: api.DataSource{
+					Beaker: k,
+				},
+			})
+		}
+
+		var hostMounts []api.DataMount
+		for _, m := range flags.mounts {
+			mount, err := parseHostMount(m)
+			if err != nil {
+				return err
+			}
+			hostMounts = append(hostMounts, *mount)
+		}
+		if len(hostMounts) > 0 {
+			// Host paths can only be validated against the local executor's
+			// configuration. Other nodes' executors validate them on start.
+			if current, err := getCurrentNode(); err == nil && current == node {
+				executorConfig, err := getExecutorConfig()
+				if err != nil {
+					return fmt.Errorf("reading executor config: %w", err)
+				}
+				if err := validateHostMounts(hostMounts, executorConfig.MountPaths); err != nil {
+					return err
+				}
+			}
+			mounts = append(mounts, hostMounts...)
+		}
 
 		var tcpPorts []api.TCPPort
-		for _, p := range ports {
+		for _, p := range flags.ports {
 			pp, err := strconv.ParseInt(p, 10, 32)
 			if err != nil {
 				return fmt.Errorf("invalid port: %d", pp)
@@ -201,12 +284,12 @@ To pass flags, use "--" e.g. "create -- ls -l"`,
 
 		session, err := beaker.CreateJob(ctx, api.JobSpec{
 			Session: &api.SessionJobSpec{
-				Workspace: workspace,
+				Workspace: flags.workspace,
 				Name:      name,
 				Node:      node,
 				Requests: &api.ResourceRequest{
-					CPUCount:     cpus,
-					GPUCount:     gpus,
+					CPUCount:     flags.cpus,
+					GPUCount:     flags.gpus,
 					Memory:       memSize,
 					SharedMemory: sharedMemSize,
 				},
@@ -222,13 +305,6 @@ To pass flags, use "--" e.g. "create -- ls -l"`,
 			return err
 		}
 
-		verificationFile, err := os.Create(session.SessionVerificationFile())
-		if err != nil {
-			return fmt.Errorf("failed to create session verification file")
-		}
-		defer verificationFile.Close()
-		defer os.Remove(verificationFile.Name())
-
 		shouldCancel, sessionID := true, session.ID
 		defer func() {
 			// If we fail to start the session, cancel it so that the executor
@@ -243,6 +319,19 @@ To pass flags, use "--" e.g. "create -- ls -l"`,
 			})
 		}()
 
+		if flags.maxIdle > 0 || flags.maxDuration > 0 {
+			if err := saveSessionLimits(session.ID, flags.maxIdle, flags.maxDuration); err != nil {
+				return fmt.Errorf("saving session limits: %w", err)
+			}
+		}
+
+		verificationFile, err := os.Create(session.SessionVerificationFile())
+		if err != nil {
+			return fmt.Errorf("failed to create session verification file")
+		}
+		defer verificationFile.Close()
+		defer os.Remove(verificationFile.Name())
+
 		if !quiet {
 			fmt.Printf("Starting session %s", color.BlueString(session.ID))
 			if req := resourceRequestString(session.Requests); req != "" {
@@ -251,10 +340,16 @@ To pass flags, use "--" e.g. "create -- ls -l"`,
 			fmt.Println("... (Press Ctrl+C to cancel)")
 		}
 
-		if session, err = awaitSessionStart(*session); err != nil {
+		if session, err = awaitSessionStart(*session, gpus); err != nil {
 			return err
 		}
 
+		if gpus != nil && len(gpus.IDs) > 0 {
+			if err := checkAssignedGPUs(gpus, session.Limits); err != nil {
+				return err
+			}
+		}
+
 		if lim := resourceLimitString(session.Limits); !quiet && lim != "" {
 			fmt.Println("Reserved", lim)
 		}
@@ -266,6 +361,15 @@ To pass flags, use "--" e.g. "create -- ls -l"`,
 		}
 		defer resp.Close()
 
+		if record != "" {
+			rec, err := newCastRecorder(record, "Beaker session "+session.ID)
+			if err != nil {
+				return err
+			}
+			defer rec.Close()
+			resp.Reader = bufio.NewReader(io.TeeReader(resp.Reader, rec))
+		}
+
 		if err := container.Start(ctx); err != nil {
 			return fmt.Errorf("start: %w", err)
 		}
@@ -352,6 +456,304 @@ Resume this session with: beaker session create
 	return cmd
 }
 
+// sessionFlags are the options of a session which may be saved to a preset.
+type sessionFlags struct {
+	image        string
+	workspace    string
+	cpus         float64
+	gpus         int
+	memory       string
+	sharedMemory string
+	ports        []string
+	secretEnv    map[string]string
+	secretMount  map[string]string
+	env          []string
+	envFile      string
+	mounts       []string
+	datasets     map[string]string
+	maxIdle      time.Duration
+	maxDuration  time.Duration
+}
+
+func addSessionFlags(cmd *cobra.Command) *sessionFlags {
+	flags := &sessionFlags{}
+	cmd.Flags().StringVarP(
+		&flags.image,
+		"image",
+		"i",
+		defaultImage,
+		"Base image to run, may be a Beaker or Docker image. Uses 'default_image' from the Beaker configuration if set.")
+	cmd.Flags().StringVarP(&flags.workspace, "workspace", "w", "", "Workspace where the session will be placed")
+
+	cmd.Flags().StringToStringVar(
+		&flags.secretEnv,
+		"secret-env",
+		map[string]string{},
+		"Secret environment variables in the format <variable>=<secret name>")
+	cmd.Flags().StringToStringVar(
+		&flags.secretMount,
+		"secret-mount",
+		map[string]string{},
+		"Secret file mounts in the format <secret name>=<file path> e.g. SECRET=/secret")
+	cmd.Flags().StringArrayVarP(
+		&flags.env,
+		"env",
+		"e",
+		[]string{},
+		"Environment variables in the format <variable>=<value>")
+	cmd.Flags().StringVar(
+		&flags.envFile,
+		"env-file",
+		"",
+		"File of environment variables with one <variable>=<value> per line. Variables set with --env take precedence.")
+	cmd.Flags().StringArrayVar(
+		&flags.mounts,
+		"mount",
+		[]string{},
+		"Host directories to mount in the format <host path>:<container path>. Host paths must be allowed by the executor's mountPaths.")
+	cmd.Flags().StringToStringVar(
+		&flags.datasets,
+		"dataset",
+		map[string]string{},
+		"Datasets to mount in the format <dataset>=<path> e.g. ai2/my-dataset=/data")
+
+	cmd.Flags().DurationVar(
+		&flags.maxIdle,
+		"max-idle",
+		0,
+		"Stop the session once it has been idle this long, e.g. 2h. Enforced by 'beaker session reap'.")
+	cmd.Flags().DurationVar(
+		&flags.maxDuration,
+		"max-duration",
+		0,
+		"Stop the session once it has run this long, e.g. 24h. Enforced by 'beaker session reap'.")
+
+	cmd.Flags().Float64Var(&flags.cpus, "cpus", 0, "Minimum CPU cores to reserve, e.g. 7.5")
+	cmd.Flags().IntVar(&flags.gpus, "gpus", 0, "Minimum number of GPUs to reserve")
+	cmd.Flags().StringVar(&flags.memory, "memory", "", "Minimum memory to reserve, e.g. 6.5GiB")
+	cmd.Flags().StringVar(&flags.sharedMemory, "shared-memory", "", "Shared memory (size of /dev/shm), e.g. 1GiB")
+	cmd.Flags().StringSliceVar(
+		&flags.ports,
+		"port",
+		[]string{},
+		"TCP container ports to expose. Each will be assigned a random, ephemeral port on the host.",
+	)
+	return flags
+}
+
+// applyPreset fills in options from a preset. Flags set explicitly on the
+// command line take precedence over the preset.
+func (f *sessionFlags) applyPreset(cmd *cobra.Command, preset config.SessionPreset) error {
+	changed := cmd.Flags().Changed
+	if !changed("image") && preset.Image != "" {
+		f.image = preset.Image
+	}
+	if !changed("workspace") && preset.Workspace != "" {
+		f.workspace = preset.Workspace
+	}
+	if !changed("cpus") && preset.CPUs != 0 {
+		f.cpus = preset.CPUs
+	}
+	if !changed("gpus") && preset.GPUs != 0 {
+		f.gpus = preset.GPUs
+	}
+	if !changed("memory") && preset.Memory != "" {
+		f.memory = preset.Memory
+	}
+	if !changed("shared-memory") && preset.SharedMemory != "" {
+		f.sharedMemory = preset.SharedMemory
+	}
+	if !changed("port") && len(preset.Ports) != 0 {
+		f.ports = preset.Ports
+	}
+	if !changed("secret-env") && len(preset.SecretEnv) != 0 {
+		f.secretEnv = preset.SecretEnv
+	}
+	if !changed("secret-mount") && len(preset.SecretMount) != 0 {
+		f.secretMount = preset.SecretMount
+	}
+	if !changed("env") && len(preset.Env) != 0 {
+		f.env = preset.Env
+	}
+	if !changed("env-file") && preset.EnvFile != "" {
+		f.envFile = preset.EnvFile
+	}
+	if !changed("mount") && len(preset.Mounts) != 0 {
+		f.mounts = preset.Mounts
+	}
+	if !changed("dataset") && len(preset.Datasets) != 0 {
+		f.datasets = preset.Datasets
+	}
+	if !changed("max-idle") && preset.MaxIdle != "" {
+		d, err := time.ParseDuration(preset.MaxIdle)
+		if err != nil {
+			return fmt.Errorf("invalid max_idle in preset: %w", err)
+		}
+		f.maxIdle = d
+	}
+	if !changed("max-duration") && preset.MaxDuration != "" {
+		d, err := time.ParseDuration(preset.MaxDuration)
+		if err != nil {
+			return fmt.Errorf("invalid max_duration in preset: %w", err)
+		}
+		f.maxDuration = d
+	}
+	return nil
+}
+
+// preset captures the flags set explicitly on the command line as a preset.
+func (f *sessionFlags) preset(cmd *cobra.Command) config.SessionPreset {
+	var preset config.SessionPreset
+	changed := cmd.Flags().Changed
+	if changed("image") {
+		preset.Image = f.image
+	}
+	if changed("workspace") {
+		preset.Workspace = f.workspace
+	}
+	if changed("cpus") {
+		preset.CPUs = f.cpus
+	}
+	if changed("gpus") {
+		preset.GPUs = f.gpus
+	}
+	if changed("memory") {
+		preset.Memory = f.memory
+	}
+	if changed("shared-memory") {
+		preset.SharedMemory = f.sharedMemory
+	}
+	if changed("port") {
+		preset.Ports = f.ports
+	}
+	if changed("secret-env") {
+		preset.SecretEnv = f.secretEnv
+	}
+	if changed("secret-mount") {
+		preset.SecretMount = f.secretMount
+	}
+	if changed("env") {
+		preset.Env = f.env
+	}
+	if changed("env-file") {
+		preset.EnvFile = f.envFile
+	}
+	if changed("mount") {
+		preset.Mounts = f.mounts
+	}
+	if changed("dataset") {
+		preset.Datasets = f.datasets
+	}
+	if changed("max-idle") {
+		preset.MaxIdle = f.maxIdle.String()
+	}
+	if changed("max-duration") {
+		preset.MaxDuration = f.maxDuration.String()
+	}
+	return preset
+}
+
+// parseEnv reads environment variables from an optional env file and a list
+// of <variable>=<value> pairs. Pairs take precedence over the file.
+func parseEnv(envFile string, pairs []string) (map[string]string, error) {
+	env := make(map[string]string)
+	if envFile != "" {
+		f, err := os.Open(envFile)
+		if err != nil {
+			return nil, fmt.Errorf("reading env file: %w", err)
+		}
+		defer f.Close()
+
+		scanner := bufio.NewScanner(f)
+		for line := 1; scanner.Scan(); line++ {
+			text := strings.TrimSpace(scanner.Text())
+			if text == "" || strings.HasPrefix(text, "#") {
+				continue
+			}
+			text = strings.TrimPrefix(text, "export ")
+
+			parts := strings.SplitN(text, "=", 2)
+			if len(parts) != 2 || strings.TrimSpace(parts[0]) == "" {
+				return nil, fmt.Errorf("%s:%d: expected <variable>=<value>", envFile, line)
+			}
+			env[strings.TrimSpace(parts[0])] = unquote(strings.TrimSpace(parts[1]))
+		}
+		if err := scanner.Err(); err != nil {
+			return nil, fmt.Errorf("reading env file: %w", err)
+		}
+	}
+
+	for _, pair := range pairs {
+		parts := strings.SplitN(pair, "=", 2)
+		if len(parts) != 2 || parts[0] == "" {
+			return nil, fmt.Errorf("invalid value for --env: %q; expected <variable>=<value>", pair)
+		}
+		env[parts[0]] = parts[1]
+	}
+	return env, nil
+}
+
+// unquote strips a matching pair of single or double quotes from a value.
+func unquote(s string) string {
+	if len(s) >= 2 && (s[0] == '"' || s[0] == '\'') && s[len(s)-1] == s[0] {
+		return s[1 : len(s)-1]
+	}
+	return s
+}
+
+// parseHostMount parses a mount in the format <host path>:<container path>.
+func parseHostMount(spec string) (*api.DataMount, error) {
+	parts := strings.Split(spec, ":")
+	if len(parts) == 3 {
+		switch parts[2] {
+		case "rw":
+			// Host mounts are writable by default.
+		case "ro":
+			return nil, fmt.Errorf("invalid mount %q: read-only mounts are not supported", spec)
+		default:
+			return nil, fmt.Errorf("invalid mount %q: unknown option %q", spec, parts[2])
+		}
+	} else if len(parts) != 2 {
+		return nil, fmt.Errorf("invalid mount %q; expected <host path>:<container path>", spec)
+	}
+
+	hostPath, err := filepath.Abs(parts[0])
+	if err != nil {
+		return nil, fmt.Errorf("invalid mount %q: %w", spec, err)
+	}
+	if !path.IsAbs(parts[1]) {
+		return nil, fmt.Errorf("invalid mount %q: container path must be absolute", spec)
+	}
+	return &api.DataMount{
+		MountPath: parts[1],
+		Source:    api.DataSource{HostPath: hostPath},
+	}, nil
+}
+
+// validateHostMounts checks that each mount's host path is within one of the
+// paths an executor allows to be mounted.
+func validateHostMounts(mounts []api.DataMount, allowed []string) error {
+	for _, mount := range mounts {
+		hostPath := mount.Source.HostPath
+		ok := false
+		for _, a := range allowed {
+			rel, err := filepath.Rel(filepath.Clean(a), hostPath)
+			if err == nil && rel != ".." && !strings.HasPrefix(rel, "../") {
+				ok = true
+				break
+			}
+		}
+		if !ok {
+			if len(allowed) == 0 {
+				return fmt.Errorf("cannot mount %s: the executor does not allow host mounts", hostPath)
+			}
+			return fmt.Errorf("cannot mount %s: host path must be within one of: %s",
+				hostPath, strings.Join(allowed, ", "))
+		}
+	}
+	return nil
+}
+
 func resourceRequestString(req *api.ResourceRequest) string {
 	if req == nil {
 		return ""
@@ -452,7 +854,7 @@ func newSessionGetCommand() *cobra.Command {
 				}
 				jobs = append(jobs, *info)
 			}
-			return printJobs(jobs)
+			return printSessions(jobs)
 		},
 	}
 }
@@ -558,11 +960,26 @@ func newSessionDescribeCommand() *cobra.Command {
 				printTableRow("ELAPSED", jobDuration(*details.Job))
 				printTableRow("STATUS", jobStatus(details.Job.Status))
 
-				var gpus string
-				if details.Limits != nil {
-					gpus = strconv.Itoa(len(details.Limits.GPUs))
+				if details.Limits == nil {
+					printTableRow("GPUS", "")
+				} else if len(details.Limits.GPUs) == 0 {
+					printTableRow("GPUS", "0")
+				} else {
+					// Devices can only be looked up on the session's own node.
+					var devices []gpuDevice
+					if current, err := getCurrentNode(); err == nil && current == session.Node {
+						devices = listGPUDevices()
+					}
+					for i, id := range details.Limits.GPUs {
+						// HACK printTableRow prints "N/A" instead of an empty string,
+						// which we don't want, so we pass a single space instead.
+						title := " "
+						if i == 0 {
+							title = "GPUS"
+						}
+						printTableRow(title, gpuString(devices, id))
+					}
 				}
-				printTableRow("GPUS", gpus)
 
 				for i, pb := range details.Runtime.TCPPorts {
 					// HACK printTableRow prints "N/A" instead of an empty string,
@@ -614,14 +1031,29 @@ func newSessionListCommand() *cobra.Command {
 	var cluster string
 	var node string
 	var finalized bool
+	var mine bool
 	cmd.Flags().BoolVar(&all, "all", false, "List all sessions.")
 	cmd.Flags().StringVar(&cluster, "cluster", "", "Cluster to list sessions.")
 	cmd.Flags().StringVar(&node, "node", "", "Node to list sessions. Defaults to current node.")
 	cmd.Flags().BoolVar(&finalized, "finalized", false, "Show only finalized sessions")
+	cmd.Flags().BoolVar(&mine, "mine", false, "List your sessions on every cluster you can access.")
 
 	cmd.RunE = func(cmd *cobra.Command, args []string) error {
 		kind := api.JobKindSession
 		opts := client.ListJobOpts{Kind: &kind}
+		if mine {
+			if cmd.Flag("node").Changed {
+				return fmt.Errorf("--mine cannot be combined with --node")
+			}
+			if !all {
+				opts.Finalized = &finalized
+			}
+			sessions, err := listUserSessions(opts, cluster)
+			if err != nil {
+				return err
+			}
+			return printSessions(sessions)
+		}
 		if !all {
 			opts.Finalized = &finalized
 			opts.Cluster = cluster
@@ -641,87 +1073,773 @@ func newSessionListCommand() *cobra.Command {
 		if err != nil {
 			return err
 		}
-		return printJobs(jobs)
+		return printSessions(jobs)
 	}
 	return cmd
 }
 
-func newSessionStopCommand() *cobra.Command {
-	cmd := &cobra.Command{
-		Use:   "stop",
-		Short: "Stop a pending or running session",
-		Args:  cobra.NoArgs,
+// printSessions prints sessions, showing GPUs by device index for sessions on
+// this machine. Devices of other machines can't be looked up.
+func printSessions(sessions []api.Job) error {
+	node, err := getCurrentNode()
+	if err != nil {
+		return printJobs(sessions)
 	}
-
-	var session string
-	cmd.Flags().StringVar(&session, "session", "", "Target session. Defaults to the running session.")
-
-	cmd.RunE = func(cmd *cobra.Command, args []string) error {
-		if session == "" {
-			info, err := findRunningSession()
-			if err != nil {
-				return err
-			}
-			session = info.ID
-		}
-
-		job, err := beaker.Job(session).Patch(ctx, api.JobPatch{
-			Status: &api.JobStatusUpdate{Canceled: true},
-		})
-		if err != nil {
-			return err
+	for _, session := range sessions {
+		if session.Node == node && session.Limits != nil && len(session.Limits.GPUs) > 0 {
+			return printJobsOnNode(sessions, node, listGPUDevices())
 		}
-		return printJobs([]api.Job{*job})
 	}
-	return cmd
+	return printJobs(sessions)
 }
 
-func awaitSessionStart(session api.Job) (*api.Job, error) {
-	s := beaker.Job(session.ID)
-	cl := beaker.Cluster(session.Cluster)
-
-	nodes, err := cl.ListClusterNodes(ctx)
-	if err != nil {
-		return nil, fmt.Errorf("couldn't list cluster nodes: %w", err)
-	}
-
-	nodesByID := make(map[string]*api.Node, len(nodes))
-	for _, node := range nodes {
-		node := node
-		nodesByID[node.ID] = &node
-	}
-
-	jobs, err := listJobs(client.ListJobOpts{
-		Cluster:   session.Cluster,
-		Finalized: api.BoolPtr(false),
-	})
+// listUserSessions lists the current user's sessions matching opts. Sessions
+// are listed from the given cluster or, if empty, from every cluster owned by
+// the user or one of their organizations.
+func listUserSessions(opts client.ListJobOpts, cluster string) ([]api.Job, error) {
+	user, err := beaker.WhoAmI(ctx)
 	if err != nil {
-		return nil, fmt.Errorf("couldn't list cluster jobs: %w", err)
+		return nil, fmt.Errorf("whoami: %w", err)
 	}
 
-	// Subtract each running job from its node's capacity.
-	for _, job := range jobs {
-		node, ok := nodesByID[job.Node]
-		if !ok || node.Limits == nil {
-			continue
+	clusters := []string{cluster}
+	if cluster == "" {
+		orgs, err := beaker.ListMyOrgs(ctx)
+		if err != nil {
+			return nil, err
 		}
-
-		// Ignore jobs which haven't fully scheduled yet, including the one we're starting.
-		if job.ID == session.ID || job.Limits == nil {
-			continue
+		accounts := []string{user.Name}
+		for _, org := range orgs {
+			accounts = append(accounts, org.Name)
 		}
 
-		node.Limits.CPUCount -= job.Limits.CPUCount
-		node.Limits.GPUCount -= len(job.Limits.GPUs)
-		if node.Limits.Memory != nil && job.Limits.Memory != nil {
-			node.Limits.Memory.Sub(*job.Limits.Memory)
+		clusters = nil
+		for _, account := range accounts {
+			var cursor string
+			for {
+				var page []api.Cluster
+				page, cursor, err = beaker.ListClusters(ctx, account, &client.ListClusterOptions{
+					Cursor: cursor,
+				})
+				if err != nil {
+					return nil, fmt.Errorf("listing clusters in %s: %w", account, err)
+				}
+				for _, c := range page {
+					clusters = append(clusters, c.FullName)
+				}
+				if cursor == "" {
+					break
+				}
+			}
 		}
 	}
 
-	var capacityErr string
+	// The list jobs API does not support filtering by user so we filter client-side.
+	// TODO: https://github.com/allenai/beaker-service/issues/1872
+	var sessions []api.Job
+	for _, cluster := range clusters {
+		opts.Cluster = cluster
+		jobs, err := listJobs(opts)
+		if err != nil {
+			return nil, fmt.Errorf("listing sessions in %s: %w", cluster, err)
+		}
+		for _, job := range jobs {
+			if job.Author.ID == user.ID {
+				sessions = append(sessions, job)
+			}
+		}
+	}
+	return sessions, nil
+}
+
+func newSessionPresetCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "preset <command>",
+		Short: "Manage session presets",
+		Long: `Manage session presets.
+
+Presets are named sets of session options stored alongside the Beaker
+configuration. Create a session from a preset with "session create --preset".`,
+	}
+	cmd.AddCommand(newSessionPresetDeleteCommand())
+	cmd.AddCommand(newSessionPresetListCommand())
+	cmd.AddCommand(newSessionPresetSaveCommand())
+	cmd.AddCommand(newSessionPresetShowCommand())
+	return cmd
+}
+
+func newSessionPresetDeleteCommand() *cobra.Command {
+	return &cobra.Command{
+		Use:   "delete <preset>",
+		Short: "Delete a session preset",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			path := config.GetSessionPresetsPath()
+			presets, err := config.ReadSessionPresets(path)
+			if err != nil {
+				return err
+			}
+			if _, ok := presets[args[0]]; !ok {
+				return fmt.Errorf("session preset not found: %s", args[0])
+			}
+			delete(presets, args[0])
+			if err := config.WriteSessionPresets(presets, path); err != nil {
+				return err
+			}
+
+			if !quiet {
+				fmt.Printf("Deleted preset %s\n", color.BlueString(args[0]))
+			}
+			return nil
+		},
+	}
+}
+
+func newSessionPresetListCommand() *cobra.Command {
+	return &cobra.Command{
+		Use:   "list",
+		Short: "List session presets",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			presets, err := config.ReadSessionPresets(config.GetSessionPresetsPath())
+			if err != nil {
+				return err
+			}
+			return printSessionPresets(presets)
+		},
+	}
+}
+
+func newSessionPresetSaveCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "save <preset>",
+		Short: "Save the given session flags as a preset",
+		Long: `Save the given session flags as a preset, replacing any existing preset with
+the same name. Only flags which are set explicitly are saved.
+
+Example: beaker session preset save jupyter-1gpu --gpus 1 --port 8888`,
+		Args: cobra.ExactArgs(1),
+	}
+
+	flags := addSessionFlags(cmd)
+
+	cmd.RunE = func(cmd *cobra.Command, args []string) error {
+		preset := flags.preset(cmd)
+		if preset.Memory != "" {
+			if _, err := bytefmt.Parse(preset.Memory); err != nil {
+				return fmt.Errorf("invalid value for --memory: %w", err)
+			}
+		}
+		if preset.SharedMemory != "" {
+			if _, err := bytefmt.Parse(preset.SharedMemory); err != nil {
+				return fmt.Errorf("invalid value for --shared-memory: %w", err)
+			}
+		}
+		if preset.Image != "" {
+			if _, err := getImageSource(preset.Image); err != nil {
+				return err
+			}
+		}
+
+		path := config.GetSessionPresetsPath()
+		presets, err := config.ReadSessionPresets(path)
+		if err != nil {
+			return err
+		}
+		presets[args[0]] = preset
+		if err := config.WriteSessionPresets(presets, path); err != nil {
+			return err
+		}
+
+		if !quiet {
+			fmt.Printf("Saved preset %s to %s\n", color.BlueString(args[0]), path)
+		}
+		return nil
+	}
+	return cmd
+}
+
+func newSessionPresetShowCommand() *cobra.Command {
+	return &cobra.Command{
+		Use:   "show <preset>",
+		Short: "Display the options of a session preset",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			presets, err := config.ReadSessionPresets(config.GetSessionPresetsPath())
+			if err != nil {
+				return err
+			}
+			preset, ok := presets[args[0]]
+			if !ok {
+				return fmt.Errorf("session preset not found: %s", args[0])
+			}
+
+			switch format {
+			case formatJSON:
+				return printJSON(preset)
+			default:
+				b, err := yaml.Marshal(preset)
+				if err != nil {
+					return err
+				}
+				_, err = os.Stdout.Write(b)
+				return err
+			}
+		},
+	}
+}
+
+func newSessionReapCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "reap",
+		Short: "Stop your sessions which are idle or have run too long",
+		Long: `Stop your sessions which are idle or have run too long.
+
+Each session is checked against the limits given by --max-idle and
+--max-duration when it was created. The same flags given to reap apply to
+sessions created without those limits. This command never prompts and is safe
+to run from cron, e.g.
+
+    */15 * * * * beaker session reap --quiet --max-idle 4h`,
+		Args: cobra.NoArgs,
+	}
+
+	var cluster string
+	var node string
+	var dryRun bool
+	var maxIdle time.Duration
+	var maxDuration time.Duration
+	cmd.Flags().StringVar(&cluster, "cluster", "", "Cluster to reap sessions.")
+	cmd.Flags().StringVar(&node, "node", "", "Node to reap sessions. Defaults to current node.")
+	cmd.Flags().BoolVar(&dryRun, "dry-run", false, "Print the sessions which would be stopped without stopping them")
+	cmd.Flags().DurationVar(&maxIdle, "max-idle", 0, "Default idle limit for sessions created without one")
+	cmd.Flags().DurationVar(&maxDuration, "max-duration", 0, "Default duration limit for sessions created without one")
+
+	cmd.RunE = func(cmd *cobra.Command, args []string) error {
+		kind := api.JobKindSession
+		opts := client.ListJobOpts{
+			Kind:      &kind,
+			Cluster:   cluster,
+			Scheduled: api.BoolPtr(true),
+			Finalized: api.BoolPtr(false),
+		}
+		if !cmd.Flag("node").Changed && cluster == "" {
+			var err error
+			if node, err = getCurrentNode(); err != nil {
+				return fmt.Errorf("failed to detect node; use --node flag: %w", err)
+			}
+		}
+		if node != "" {
+			opts.Node = &node
+		}
+
+		sessions, err := listJobs(opts)
+		if err != nil {
+			return err
+		}
+		user, err := beaker.WhoAmI(ctx)
+		if err != nil {
+			return fmt.Errorf("whoami: %w", err)
+		}
+
+		limitsPath := config.GetSessionLimitsPath()
+		limits, err := config.ReadSessionLimits(limitsPath)
+		if err != nil {
+			return err
+		}
+
+		var failed int
+		for _, session := range sessions {
+			// The list jobs API does not support filtering by user so we filter client-side.
+			if session.Author.ID != user.Identity.ID || session.Status.Canceled != nil {
+				continue
+			}
+
+			// Limits set for the session override the defaults one at a time.
+			idleLimit, durationLimit := maxIdle, maxDuration
+			if l, ok := limits[session.ID]; ok {
+				sessionIdle, sessionDuration, err := parseSessionLimits(l)
+				if err != nil {
+					fmt.Fprintln(os.Stderr, color.RedString("Error:"), session.ID, err)
+					failed++
+					continue
+				}
+				if l.MaxIdle != "" {
+					idleLimit = sessionIdle
+				}
+				if l.MaxDuration != "" {
+					durationLimit = sessionDuration
+				}
+			}
+
+			reason := sessionReapReason(session, idleLimit, durationLimit)
+			if reason == "" {
+				continue
+			}
+
+			if dryRun {
+				fmt.Printf("Would stop session %s: %s\n", color.BlueString(session.ID), reason)
+				continue
+			}
+
+			message := "Stopped by 'beaker session reap': " + reason
+			if _, err := beaker.Job(session.ID).Patch(ctx, api.JobPatch{
+				Status: &api.JobStatusUpdate{Canceled: true, Message: &message},
+			}); err != nil {
+				// Stop as many sessions as possible, reporting failures at the end.
+				fmt.Fprintln(os.Stderr, color.RedString("Error:"), session.ID, err)
+				failed++
+				continue
+			}
+			delete(limits, session.ID)
+
+			if quiet {
+				fmt.Println(session.ID)
+			} else {
+				fmt.Printf("Stopped session %s: %s\n", color.BlueString(session.ID), reason)
+			}
+		}
+
+		if !dryRun {
+			// Forget limits of sessions which have already ended.
+			for id := range limits {
+				job, err := beaker.Job(id).Get(ctx)
+				if err != nil {
+					if apiErr, ok := err.(api.Error); ok && apiErr.Code == http.StatusNotFound {
+						delete(limits, id)
+					}
+					continue
+				}
+				if job.Status.Finalized != nil {
+					delete(limits, id)
+				}
+			}
+			if err := config.WriteSessionLimits(limits, limitsPath); err != nil {
+				return err
+			}
+		}
+
+		if failed > 0 {
+			return fmt.Errorf("failed to reap %d sessions", failed)
+		}
+		return nil
+	}
+	return cmd
+}
+
+// sessionReapReason returns why a session has exceeded its limits, or an empty
+// string if it has not. Zero limits are ignored.
+func sessionReapReason(session api.Job, maxIdle, maxDuration time.Duration) string {
+	if maxIdle > 0 && session.Status.IdleSince != nil {
+		if idle := time.Since(*session.Status.IdleSince); idle > maxIdle {
+			return fmt.Sprintf("idle for %s (limit %s)", idle.Round(time.Minute), maxIdle)
+		}
+	}
+	if maxDuration > 0 {
+		if duration := jobDuration(session); duration > maxDuration {
+			return fmt.Sprintf("running for %s (limit %s)", duration.Round(time.Minute), maxDuration)
+		}
+	}
+	return ""
+}
+
+func parseSessionLimits(limits config.SessionLimits) (maxIdle, maxDuration time.Duration, err error) {
+	if limits.MaxIdle != "" {
+		if maxIdle, err = time.ParseDuration(limits.MaxIdle); err != nil {
+			return 0, 0, fmt.Errorf("invalid max_idle: %w", err)
+		}
+	}
+	if limits.MaxDuration != "" {
+		if maxDuration, err = time.ParseDuration(limits.MaxDuration); err != nil {
+			return 0, 0, fmt.Errorf("invalid max_duration: %w", err)
+		}
+	}
+	return maxIdle, maxDuration, nil
+}
+
+// saveSessionLimits records limits of a new session to be enforced by "session reap".
+func saveSessionLimits(sessionID string, maxIdle, maxDuration time.Duration) error {
+	path := config.GetSessionLimitsPath()
+	limits, err := config.ReadSessionLimits(path)
+	if err != nil {
+		return err
+	}
+
+	var l config.SessionLimits
+	if maxIdle > 0 {
+		l.MaxIdle = maxIdle.String()
+	}
+	if maxDuration > 0 {
+		l.MaxDuration = maxDuration.String()
+	}
+	limits[sessionID] = l
+	return config.WriteSessionLimits(limits, path)
+}
+
+func newSessionReplayCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "replay <file>",
+		Short: "Play back a session recorded with --record",
+		Long: `Play back a session recorded with --record.
+
+Recordings are asciinema cast files and may also be played with asciinema.`,
+		Args: cobra.ExactArgs(1),
+	}
+
+	var speed float64
+	var maxWait time.Duration
+	cmd.Flags().Float64Var(&speed, "speed", 1, "Playback speed multiplier")
+	cmd.Flags().DurationVar(&maxWait, "max-wait", 0, "Maximum pause between outputs e.g. 2s. Unlimited by default.")
+
+	cmd.RunE = func(cmd *cobra.Command, args []string) error {
+		if speed <= 0 {
+			return fmt.Errorf("--speed must be positive")
+		}
+
+		f, err := os.Open(args[0])
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+
+		return replayCast(f, os.Stdout, speed, maxWait)
+	}
+	return cmd
+}
+
+func newSessionSnapshotCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "snapshot",
+		Short: "Save a running session's root filesystem as an image without stopping it",
+		Long: `Save a running session's root filesystem as an image without stopping it.
+
+The session's container is committed and pushed to a new Beaker image. Processes
+in the session keep running, but anything they write during the snapshot may
+not be captured. Do not write sensitive information outside of the home
+directory; it will be included in the image.`,
+		Args: cobra.NoArgs,
+	}
+
+	var session string
+	var name string
+	var description string
+	var workspace string
+	cmd.Flags().StringVar(&session, "session", "", "Target session. Defaults to the running session.")
+	cmd.Flags().StringVarP(&name, "name", "n", "", "Image name")
+	cmd.Flags().StringVar(&description, "description", "", "Image description")
+	cmd.Flags().StringVarP(&workspace, "workspace", "w", "", "Image workspace. Defaults to the session's workspace.")
+
+	cmd.RunE = func(cmd *cobra.Command, args []string) error {
+		container, info, err := findRunningSessionContainer(session)
+		if err != nil {
+			return err
+		}
+
+		if workspace == "" {
+			workspace = info.Workspace
+		}
+		if description == "" {
+			description = "Snapshot of session " + info.ID
+		}
+
+		if !quiet {
+			fmt.Printf("Committing session %s...\n", color.BlueString(info.ID))
+		}
+		imageID, err := container.Commit(ctx)
+		if err != nil {
+			return err
+		}
+		defer func() {
+			// The image has been pushed to Beaker so the local copy is no
+			// longer needed. Cleaning up is best-effort.
+			_ = removeImage(imageID)
+		}()
+
+		image, err := createImage(imageID, name, description, workspace)
+		if err != nil {
+			return err
+		}
+
+		if quiet {
+			fmt.Println(image.Ref())
+		} else {
+			fmt.Printf(`Image saved to %s: %s/im/%s
+Start a session from this snapshot with: beaker session create --image beaker://%s
+`, color.BlueString(image.Ref()), beaker.Address(), image.Ref(), image.Ref())
+		}
+		return nil
+	}
+	return cmd
+}
+
+func newSessionStatsCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "stats",
+		Short: "Display live resource usage of a running session",
+		Long: `Display live resource usage of a running session.
+
+Usage is sampled from the session's container and compared against the
+resources reserved for the session. Press Ctrl+C to stop.`,
+		Args: cobra.NoArgs,
+	}
+
+	var session string
+	var once bool
+	var interval time.Duration
+	cmd.Flags().StringVar(&session, "session", "", "Target session. Defaults to the running session.")
+	cmd.Flags().BoolVar(&once, "once", false, "Print a single sample and exit")
+	cmd.Flags().DurationVar(&interval, "interval", 2*time.Second, "Interval between samples")
+
+	cmd.RunE = func(cmd *cobra.Command, args []string) error {
+		if interval <= 0 {
+			return fmt.Errorf("invalid value for --interval: must be positive")
+		}
+
+		container, info, err := findRunningSessionContainer(session)
+		if err != nil {
+			return err
+		}
+
+		if lim := resourceLimitString(info.Limits); !quiet && format != formatJSON && lim != "" {
+			fmt.Println("Reserved", lim)
+		}
+
+		// Each sample is flushed as soon as it's read, so use a minimum cell
+		// width to keep columns aligned from one sample to the next.
+		w := tabwriter.NewWriter(os.Stdout, 26, 0, 2, ' ', 0)
+		if format != formatJSON {
+			fmt.Fprintln(w, "TIME\tCPU\tMEMORY\tNET I/O\tBLOCK I/O")
+		}
+
+		delay := time.NewTimer(0) // No delay on first sample.
+		defer delay.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return ctx.Err()
+
+			case <-delay.C:
+				stats, err := container.Stats(ctx)
+				if err != nil {
+					return fmt.Errorf("reading container stats: %w", err)
+				}
+
+				usage := newSessionUsage(info.Limits, stats)
+				switch format {
+				case formatJSON:
+					if err := printJSON(usage); err != nil {
+						return err
+					}
+				default:
+					fmt.Fprintf(w, "%s\t%s\t%s\t%s / %s\t%s / %s\n",
+						usage.Time.Local().Format("15:04:05"),
+						cpuUsageString(usage.CPUCount, usage.CPULimit),
+						memoryUsageString(usage.MemoryBytes, usage.MemoryLimit),
+						bytefmt.New(usage.NetworkRxBytes, bytefmt.Binary),
+						bytefmt.New(usage.NetworkTxBytes, bytefmt.Binary),
+						bytefmt.New(usage.BlockReadBytes, bytefmt.Binary),
+						bytefmt.New(usage.BlockWriteBytes, bytefmt.Binary))
+					if err := w.Flush(); err != nil {
+						return err
+					}
+				}
+
+				if once {
+					return nil
+				}
+				delay.Reset(interval)
+			}
+		}
+	}
+	return cmd
+}
+
+// sessionUsage is a single sample of a session's resource usage alongside the
+// resources reserved for it. This is the information output by `session stats`.
+type sessionUsage struct {
+	Time            time.Time     `json:"time"`
+	CPUCount        float64       `json:"cpuCount"`
+	CPULimit        float64       `json:"cpuLimit,omitempty"`
+	MemoryBytes     int64         `json:"memoryBytes"`
+	MemoryLimit     *bytefmt.Size `json:"memoryLimit,omitempty"`
+	NetworkRxBytes  int64         `json:"networkRxBytes"`
+	NetworkTxBytes  int64         `json:"networkTxBytes"`
+	BlockReadBytes  int64         `json:"blockReadBytes"`
+	BlockWriteBytes int64         `json:"blockWriteBytes"`
+}
+
+func newSessionUsage(limits *api.ResourceLimits, stats *runtime.ContainerStats) *sessionUsage {
+	usage := &sessionUsage{
+		Time: stats.Time,
+
+		// The runtime reports CPU usage as a percentage of a single core.
+		CPUCount:        stats.Stats[runtime.CPUUsagePercentStat] / 100,
+		MemoryBytes:     int64(stats.Stats[runtime.MemoryUsageBytesStat]),
+		NetworkRxBytes:  int64(stats.Stats[runtime.NetworkRxBytesStat]),
+		NetworkTxBytes:  int64(stats.Stats[runtime.NetworkTxBytesStat]),
+		BlockReadBytes:  int64(stats.Stats[runtime.BlockReadBytesStat]),
+		BlockWriteBytes: int64(stats.Stats[runtime.BlockWriteBytesStat]),
+	}
+	if limits != nil {
+		usage.CPULimit = limits.CPUCount
+		usage.MemoryLimit = limits.Memory
+	}
+	return usage
+}
+
+// cpuUsageString formats CPU usage relative to its limit e.g. "1.50 / 4 CPUs (38%)".
+func cpuUsageString(used float64, limit float64) string {
+	if limit <= 0 {
+		return fmt.Sprintf("%.2f CPUs", used)
+	}
+	return fmt.Sprintf("%.2f / %s CPUs (%.0f%%)",
+		used, strconv.FormatFloat(limit, 'f', -1, 64), 100*used/limit)
+}
+
+// memoryUsageString formats memory usage relative to its limit e.g. "3 GiB / 8 GiB (38%)".
+func memoryUsageString(used int64, limit *bytefmt.Size) string {
+	usedSize := bytefmt.New(used, bytefmt.Binary)
+	if limit == nil || limit.IsZero() {
+		return usedSize.String()
+	}
+	return fmt.Sprintf("%v / %v (%.0f%%)",
+		usedSize, limit, 100*float64(used)/float64(limit.Int64()))
+}
+
+func newSessionStopCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "stop",
+		Short: "Stop a pending or running session",
+		Args:  cobra.NoArgs,
+	}
+
+	var session string
+	cmd.Flags().StringVar(&session, "session", "", "Target session. Defaults to the running session.")
+
+	cmd.RunE = func(cmd *cobra.Command, args []string) error {
+		if session == "" {
+			info, err := findRunningSession()
+			if err != nil {
+				return err
+			}
+			session = info.ID
+		}
+
+		job, err := beaker.Job(session).Patch(ctx, api.JobPatch{
+			Status: &api.JobStatusUpdate{Canceled: true},
+		})
+		if err != nil {
+			return err
+		}
+		return printJobs([]api.Job{*job})
+	}
+	return cmd
+}
+
+// newGPURequest builds GPU placement constraints for a new session and checks
+// that they can be satisfied on the target node.
+func newGPURequest(
+	cmd *cobra.Command,
+	flags *sessionFlags,
+	node string,
+	ids []string,
+	gpuType string,
+) (*gpuRequest, error) {
+	req := &gpuRequest{Type: gpuType, IDs: ids}
+	if len(ids) > 0 {
+		if cmd.Flag("gpus").Changed && flags.gpus != len(ids) {
+			return nil, fmt.Errorf("--gpus must match the number of GPUs in --gpu-ids")
+		}
+		flags.gpus = len(ids)
+
+		// Devices can only be resolved on the machine they're attached to.
+		if current, err := getCurrentNode(); err != nil || current != node {
+			return nil, fmt.Errorf("--gpu-ids is only available on the current node")
+		}
+		req.Devices = listGPUDevices()
+		if err := validateGPUIDs(req.Devices, ids); err != nil {
+			return nil, err
+		}
+	}
+	if flags.gpus == 0 {
+		return nil, fmt.Errorf("--gpu-type requires --gpus or --gpu-ids")
+	}
+
+	n, err := beaker.Node(node).Get(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("getting node: %w", err)
+	}
+	jobs, err := listJobs(client.ListJobOpts{
+		Node:      &node,
+		Finalized: api.BoolPtr(false),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("listing node jobs: %w", err)
+	}
+	for _, job := range jobs {
+		if job.Limits != nil {
+			req.InUse = append(req.InUse, job.Limits.GPUs...)
+		}
+	}
+	if err := checkNodeGPUs(n, req); err != nil {
+		return nil, fmt.Errorf("can't create session because %s", err)
+	}
+	return req, nil
+}
+
+// checkAssignedGPUs checks that a started session was assigned the GPUs it
+// requires. The executor assigns GPUs itself and resource requests can't name
+// specific devices, so the assignment can only be checked after the fact.
+func checkAssignedGPUs(req *gpuRequest, limits *api.ResourceLimits) error {
+	var assigned []string
+	if limits != nil {
+		assigned = gpuIndices(req.Devices, limits.GPUs)
+	}
+	requested := gpuIndices(req.Devices, req.IDs)
+
+	want := make(map[string]bool)
+	for _, id := range requested {
+		want[id] = true
+	}
+	for _, id := range assigned {
+		if !want[id] {
+			return fmt.Errorf(
+				"session was assigned GPUs %s instead of %s and has been canceled; "+
+					"try again once other sessions release them",
+				strings.Join(assigned, ","),
+				strings.Join(requested, ","))
+		}
+	}
+	return nil
+}
+
+func awaitSessionStart(session api.Job, gpus *gpuRequest) (*api.Job, error) {
+	s := beaker.Job(session.ID)
+
+	// Ignore the session we're starting since it hasn't fully scheduled yet.
+	nodes, err := getNodeUtilization(session.Cluster, session.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	nodesByID := make(map[string]*api.Node, len(nodes))
+	for _, node := range nodes {
+		nodesByID[node.ID] = node.freeNode()
+		if gpus != nil && node.ID == session.Node {
+			gpus.InUse = append(gpus.InUse, node.UsedGPUs...)
+		}
+	}
+
+	// Specific devices only apply to the requested node.
+	var otherGPUs *gpuRequest
+	if gpus != nil {
+		otherGPUs = &gpuRequest{Type: gpus.Type}
+	}
+
+	var capacityErr string
 	if node, ok := nodesByID[session.Node]; !ok {
 		capacityErr = "the node has been deleted"
-	} else if err := checkNodeCapacity(node, session.Requests); err != nil {
+	} else if err := checkNodeCapacity(node, session.Requests, gpus); err != nil {
 		capacityErr = err.Error()
 	}
 
@@ -735,7 +1853,7 @@ func awaitSessionStart(session api.Job) (*api.Job, error) {
 			}
 
 			// Skip nodes where the session won't fit.
-			if checkNodeCapacity(node, session.Requests) != nil {
+			if checkNodeCapacity(node, session.Requests, otherGPUs) != nil {
 				continue
 			}
 
@@ -770,7 +1888,7 @@ func awaitSessionStart(session api.Job) (*api.Job, error) {
 	return job, await(ctx, "Waiting for session to start", started, 0)
 }
 
-func checkNodeCapacity(node *api.Node, request *api.ResourceRequest) error {
+func checkNodeCapacity(node *api.Node, request *api.ResourceRequest, gpus *gpuRequest) error {
 	switch {
 	case node.Limits == nil:
 		// Node has unknown capacity. Treat it as unbounded.
@@ -799,7 +1917,7 @@ func checkNodeCapacity(node *api.Node, request *api.ResourceRequest) error {
 		return errors.New("the node has no space left")
 
 	default:
-		return nil // All checks passed.
+		return checkNodeGPUs(node, gpus)
 	}
 }
 
@@ -868,6 +1986,9 @@ func findRunningSession() (*api.Job, error) {
 		return nil, fmt.Errorf("no running sessions found")
 	}
 	if len(userSessions) > 1 {
+		if !quiet && isInteractive() {
+			return selectSession(userSessions)
+		}
 		if !quiet {
 			if err := printJobs(userSessions); err != nil {
 				return nil, err
@@ -882,6 +2003,34 @@ func findRunningSession() (*api.Job, error) {
 	return &session, nil
 }
 
+// selectSession prompts the user to pick one of several sessions.
+func selectSession(sessions []api.Job) (*api.Job, error) {
+	fmt.Println("Multiple running sessions found:")
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	for i, session := range sessions {
+		var gpus int
+		if session.Limits != nil {
+			gpus = len(session.Limits.GPUs)
+		}
+		name := session.Name
+		if name == "" {
+			name = "-"
+		}
+		fmt.Fprintf(w, "  %d)\t%s\t%s\tup %s\t%d GPUs\n",
+			i+1, session.ID, name, jobDuration(session).Round(time.Second), gpus)
+	}
+	if err := w.Flush(); err != nil {
+		return nil, err
+	}
+
+	i, err := choose("Select a session", len(sessions))
+	if err != nil {
+		return nil, err
+	}
+	session := sessions[i]
+	return &session, nil
+}
+
 // Find a running container for a session.
 func findRunningContainer(session api.Job) (*docker.Container, error) {
 	if session.Status.Started == nil {
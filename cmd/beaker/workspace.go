@@ -17,6 +17,7 @@ func newWorkspaceCommand() *cobra.Command {
 		Short: "Manage workspaces",
 	}
 	cmd.AddCommand(newWorkspaceArchiveCommand())
+	cmd.AddCommand(newWorkspaceCostCommand())
 	cmd.AddCommand(newWorkspaceCreateCommand())
 	cmd.AddCommand(newWorkspaceDatasetsCommand())
 	cmd.AddCommand(newWorkspaceExperimentsCommand())
@@ -50,6 +51,51 @@ func newWorkspaceArchiveCommand() *cobra.Command {
 	}
 }
 
+func newWorkspaceCostCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "cost <workspace>",
+		Short: "Estimate the cost of experiments in a workspace",
+		Long: `Estimate the cost of experiments in a workspace.
+
+Costs are estimated as in "beaker cluster cost" from the jobs of every
+experiment in the workspace. Use --format=csv for spreadsheets.`,
+		Args: cobra.ExactArgs(1),
+	}
+	flags := addCostFlags(cmd, "experiment")
+
+	cmd.RunE = func(cmd *cobra.Command, args []string) error {
+		since, err := parseSince(flags.since)
+		if err != nil {
+			return err
+		}
+
+		workspace := beaker.Workspace(args[0])
+		var jobs []api.Job
+		var cursor string
+		for {
+			var page []api.Experiment
+			if page, cursor, err = workspace.Experiments(ctx, &client.ListExperimentOptions{
+				Cursor: cursor,
+			}); err != nil {
+				return err
+			}
+			for _, experiment := range page {
+				jobs = append(jobs, experiment.Jobs...)
+			}
+			if cursor == "" {
+				break
+			}
+		}
+
+		costs, err := groupJobCosts(jobs, since, flags.groupBy)
+		if err != nil {
+			return err
+		}
+		return printJobCosts(costs)
+	}
+	return cmd
+}
+
 func newWorkspaceCreateCommand() *cobra.Command {
 	cmd := &cobra.Command{
 		Use:   "create <name>",
@@ -0,0 +1,174 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+func encodeYAML(node *yaml.Node) ([]byte, error) {
+	var buf bytes.Buffer
+	enc := yaml.NewEncoder(&buf)
+	enc.SetIndent(2)
+	if err := enc.Encode(node); err != nil {
+		return nil, err
+	}
+	if err := enc.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// findYAMLNode finds the value at a dotted key within a mapping.
+func findYAMLNode(node *yaml.Node, key string) *yaml.Node {
+	for _, k := range strings.Split(key, ".") {
+		if node.Kind != yaml.MappingNode {
+			return nil
+		}
+		var next *yaml.Node
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			if node.Content[i].Value == k {
+				next = node.Content[i+1]
+				break
+			}
+		}
+		if next == nil {
+			return nil
+		}
+		node = next
+	}
+	return node
+}
+
+// yamlPathElem is a single step in a YAML path: a mapping key, a sequence
+// index or every element of a sequence.
+type yamlPathElem struct {
+	key   string
+	index int // -1 for every element.
+	isKey bool
+}
+
+// parseYAMLPath parses a path such as "tasks[0].resources.gpuCount". An index
+// of "*" matches every element of a sequence, e.g. "tasks[*].context".
+func parseYAMLPath(path string) ([]yamlPathElem, error) {
+	var elems []yamlPathElem
+	for _, part := range strings.Split(path, ".") {
+		key := part
+		var indices []string
+		if i := strings.Index(part, "["); i >= 0 {
+			key = part[:i]
+			rest := part[i:]
+			for rest != "" {
+				end := strings.Index(rest, "]")
+				if rest[0] != '[' || end < 0 {
+					return nil, fmt.Errorf("invalid path %q", path)
+				}
+				indices = append(indices, rest[1:end])
+				rest = rest[end+1:]
+			}
+		}
+
+		if key != "" {
+			elems = append(elems, yamlPathElem{key: key, isKey: true})
+		} else if len(elems) > 0 || len(indices) == 0 {
+			return nil, fmt.Errorf("invalid path %q", path)
+		}
+		for _, index := range indices {
+			if index == "*" {
+				elems = append(elems, yamlPathElem{index: -1})
+				continue
+			}
+			i, err := strconv.Atoi(index)
+			if err != nil || i < 0 {
+				return nil, fmt.Errorf("invalid index %q in path %q", index, path)
+			}
+			elems = append(elems, yamlPathElem{index: i})
+		}
+	}
+	return elems, nil
+}
+
+// setYAMLPath sets the value at a path, creating missing mapping keys.
+// Indices may refer to an existing element or append one to the end of a
+// sequence. Comments on a replaced value are kept.
+func setYAMLPath(node *yaml.Node, path []yamlPathElem, value *yaml.Node) error {
+	return setYAMLPathAt(node, path, value, "")
+}
+
+// setYAMLPathAt implements setYAMLPath. The path to the current node is used
+// in errors.
+func setYAMLPathAt(node *yaml.Node, path []yamlPathElem, value *yaml.Node, at string) error {
+	if len(path) == 0 {
+		v := copyYAMLNode(value)
+		v.HeadComment = node.HeadComment
+		v.LineComment = node.LineComment
+		v.FootComment = node.FootComment
+		*node = *v
+		return nil
+	}
+
+	elem := path[0]
+	name := at
+	if name == "" {
+		name = "the document"
+	}
+	isEmpty := node.Kind == 0 || (node.Kind == yaml.ScalarNode && node.Tag == "!!null")
+	if elem.isKey {
+		if isEmpty {
+			*node = yaml.Node{Kind: yaml.MappingNode, Tag: "!!map"}
+		}
+		if node.Kind != yaml.MappingNode {
+			return fmt.Errorf("%s is not a mapping", name)
+		}
+		next := at + "." + elem.key
+		if at == "" {
+			next = elem.key
+		}
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			if node.Content[i].Value == elem.key {
+				return setYAMLPathAt(node.Content[i+1], path[1:], value, next)
+			}
+		}
+		child := &yaml.Node{}
+		node.Content = append(node.Content,
+			&yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: elem.key},
+			child)
+		return setYAMLPathAt(child, path[1:], value, next)
+	}
+
+	if isEmpty {
+		*node = yaml.Node{Kind: yaml.SequenceNode, Tag: "!!seq"}
+	}
+	if node.Kind != yaml.SequenceNode {
+		return fmt.Errorf("%s is not a list", name)
+	}
+	switch {
+	case elem.index < 0:
+		for i, item := range node.Content {
+			if err := setYAMLPathAt(item, path[1:], value, fmt.Sprintf("%s[%d]", at, i)); err != nil {
+				return err
+			}
+		}
+		return nil
+	case elem.index < len(node.Content):
+		return setYAMLPathAt(node.Content[elem.index], path[1:], value, fmt.Sprintf("%s[%d]", at, elem.index))
+	case elem.index == len(node.Content):
+		child := &yaml.Node{}
+		node.Content = append(node.Content, child)
+		return setYAMLPathAt(child, path[1:], value, fmt.Sprintf("%s[%d]", at, elem.index))
+	default:
+		return fmt.Errorf("%s[%d] is out of range; %s has %d items", at, elem.index, name, len(node.Content))
+	}
+}
+
+func copyYAMLNode(node *yaml.Node) *yaml.Node {
+	c := *node
+	c.Content = make([]*yaml.Node, len(node.Content))
+	for i, child := range node.Content {
+		c.Content[i] = copyYAMLNode(child)
+	}
+	return &c
+}
@@ -18,6 +18,9 @@ type Config struct {
 	DefaultWorkspace string `yaml:"default_workspace,omitempty"`
 	DefaultImage     string `yaml:"default_image,omitempty"`
 	HTTPDiag         bool   `yaml:"http_diag,omitempty"`
+
+	// Executor settings
+	ExecutorMirror string `yaml:"executor_mirror,omitempty"`
 }
 
 const (
@@ -0,0 +1,123 @@
+package config
+
+import (
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	"github.com/pkg/errors"
+	"gopkg.in/yaml.v3"
+)
+
+const sessionPresetsFile = "sessions.yml"
+
+// SessionPreset is a named set of options used to create sessions.
+type SessionPreset struct {
+	Image        string            `yaml:"image,omitempty" json:"image,omitempty"`
+	Workspace    string            `yaml:"workspace,omitempty" json:"workspace,omitempty"`
+	CPUs         float64           `yaml:"cpus,omitempty" json:"cpus,omitempty"`
+	GPUs         int               `yaml:"gpus,omitempty" json:"gpus,omitempty"`
+	Memory       string            `yaml:"memory,omitempty" json:"memory,omitempty"`
+	SharedMemory string            `yaml:"shared_memory,omitempty" json:"shared_memory,omitempty"`
+	Ports        []string          `yaml:"ports,omitempty" json:"ports,omitempty"`
+	SecretEnv    map[string]string `yaml:"secret_env,omitempty" json:"secret_env,omitempty"`
+	SecretMount  map[string]string `yaml:"secret_mount,omitempty" json:"secret_mount,omitempty"`
+	Env          []string          `yaml:"env,omitempty" json:"env,omitempty"`
+	EnvFile      string            `yaml:"env_file,omitempty" json:"env_file,omitempty"`
+	Mounts       []string          `yaml:"mounts,omitempty" json:"mounts,omitempty"`
+	Datasets     map[string]string `yaml:"datasets,omitempty" json:"datasets,omitempty"`
+	MaxIdle      string            `yaml:"max_idle,omitempty" json:"max_idle,omitempty"`
+	MaxDuration  string            `yaml:"max_duration,omitempty" json:"max_duration,omitempty"`
+}
+
+// GetSessionPresetsPath returns the path of the session presets file, which
+// is stored alongside the Beaker config file.
+func GetSessionPresetsPath() string {
+	return filepath.Join(filepath.Dir(GetFilePath()), sessionPresetsFile)
+}
+
+// ReadSessionPresets reads session presets keyed by name. A missing file is
+// treated as empty.
+func ReadSessionPresets(path string) (map[string]SessionPreset, error) {
+	presets := map[string]SessionPreset{}
+
+	r, err := os.Open(path)
+	if os.IsNotExist(err) {
+		return presets, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer r.Close()
+
+	d := yaml.NewDecoder(r)
+	if err := d.Decode(&presets); err != nil && err != io.EOF {
+		return nil, errors.Wrap(err, "failed to read session presets")
+	}
+	return presets, nil
+}
+
+func WriteSessionPresets(presets map[string]SessionPreset, filePath string) error {
+	bytes, err := yaml.Marshal(presets)
+	if err != nil {
+		return err
+	}
+
+	dirPath, _ := filepath.Split(filePath)
+	if err := os.MkdirAll(dirPath, os.ModePerm); err != nil {
+		return errors.WithStack(err)
+	}
+
+	return ioutil.WriteFile(filePath, bytes, 0644)
+}
+
+const sessionLimitsFile = "session-limits.yml"
+
+// SessionLimits bound the lifetime of a session. Limits are enforced by
+// "beaker session reap" and are formatted as Go durations e.g. "2h".
+type SessionLimits struct {
+	MaxIdle     string `yaml:"max_idle,omitempty" json:"max_idle,omitempty"`
+	MaxDuration string `yaml:"max_duration,omitempty" json:"max_duration,omitempty"`
+}
+
+// GetSessionLimitsPath returns the path of the session limits file, which is
+// stored alongside the Beaker config file.
+func GetSessionLimitsPath() string {
+	return filepath.Join(filepath.Dir(GetFilePath()), sessionLimitsFile)
+}
+
+// ReadSessionLimits reads session limits keyed by session ID. A missing file
+// is treated as empty.
+func ReadSessionLimits(path string) (map[string]SessionLimits, error) {
+	limits := map[string]SessionLimits{}
+
+	r, err := os.Open(path)
+	if os.IsNotExist(err) {
+		return limits, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer r.Close()
+
+	d := yaml.NewDecoder(r)
+	if err := d.Decode(&limits); err != nil && err != io.EOF {
+		return nil, errors.Wrap(err, "failed to read session limits")
+	}
+	return limits, nil
+}
+
+func WriteSessionLimits(limits map[string]SessionLimits, filePath string) error {
+	bytes, err := yaml.Marshal(limits)
+	if err != nil {
+		return err
+	}
+
+	dirPath, _ := filepath.Split(filePath)
+	if err := os.MkdirAll(dirPath, os.ModePerm); err != nil {
+		return errors.WithStack(err)
+	}
+
+	return ioutil.WriteFile(filePath, bytes, 0644)
+}